@@ -1,9 +1,15 @@
 package bob
 
+import (
+	"github.com/bitcoinschema/go-bpu"
+	crypto "github.com/bsv-blockchain/go-sdk/primitives/hash"
+	"github.com/bsv-blockchain/go-sdk/script"
+)
+
 // InputAddresses returns the Bitcoin addresses for the transaction inputs
 func (t *Tx) InputAddresses() (addresses []string) {
 	for _, i := range t.In {
-		if *i.E.A != "" && *i.E.A != "false" {
+		if isAddress(i.E.A) {
 			addresses = append(addresses, *i.E.A)
 		}
 	}
@@ -11,11 +17,166 @@ func (t *Tx) InputAddresses() (addresses []string) {
 }
 
 // OutputAddresses returns the Bitcoin addresses for the transaction outputs
+//
+// When E.A is not set the addresses are derived from the locking script
+// (see AddressesByOutput)
 func (t *Tx) OutputAddresses() (addresses []string) {
-	for _, i := range t.In {
-		if *i.E.A != "" && *i.E.A != "false" {
-			addresses = append(addresses, *i.E.A)
-		}
+	for i := range t.Out {
+		addresses = append(addresses, outputAddresses(&t.Out[i])...)
 	}
 	return
 }
+
+// AddressesByOutput returns the Bitcoin addresses of each output, keyed by output index
+//
+// When E.A is not set the addresses are derived from the locking script for
+// P2PKH, P2PK, bare multisig and 1Sat ordinal wrapped P2PKH outputs.
+// Outputs without an address are left out.
+func (t *Tx) AddressesByOutput() map[uint32][]string {
+	addresses := make(map[uint32][]string)
+	for i := range t.Out {
+		if a := outputAddresses(&t.Out[i]); len(a) > 0 {
+			addresses[uint32(i)] = a
+		}
+	}
+	return addresses
+}
+
+// isAddress returns true if the E.A value is set (bitbus uses "false" for no address)
+func isAddress(a *string) bool {
+	return a != nil && *a != "" && *a != "false"
+}
+
+// outputAddresses returns the addresses of a single output
+func outputAddresses(out *bpu.Output) []string {
+	if isAddress(out.E.A) {
+		return []string{*out.E.A}
+	}
+	return scriptAddresses(outputCells(out))
+}
+
+// outputCells returns the cells of all tapes of an output in order
+func outputCells(out *bpu.Output) []bpu.Cell {
+	var cells []bpu.Cell
+	for _, tape := range out.Tape {
+		cells = append(cells, tape.Cell...)
+	}
+	return cells
+}
+
+// scriptAddresses derives the addresses from the cells of a locking script
+func scriptAddresses(cells []bpu.Cell) []string {
+	// 1Sat ordinals wrap a P2PKH script with an inscription envelope
+	// placed either before or after it, and may add OP_RETURN data
+	if start, end, ok := findEnvelope(cells); ok {
+		if start == 0 {
+			cells = cells[end:]
+		} else {
+			cells = cells[:start]
+		}
+	}
+	for i := 1; i < len(cells); i++ {
+		if isOp(&cells[i], script.OpRETURN) {
+			cells = cells[:i]
+			break
+		}
+	}
+
+	switch {
+	case isP2PKHCells(cells):
+		pkh, _ := cellData(&cells[2])
+		a, err := script.NewAddressFromPublicKeyHash(pkh, true)
+		if err != nil {
+			return nil
+		}
+		return []string{a.AddressString}
+	case len(cells) == 2 && isOp(&cells[1], script.OpCHECKSIG):
+		// P2PK
+		if a := pubKeyAddress(&cells[0]); a != "" {
+			return []string{a}
+		}
+	case len(cells) >= 4 && isOp(&cells[len(cells)-1], script.OpCHECKMULTISIG):
+		// bare multisig: OP_m <pubkey>... OP_n OP_CHECKMULTISIG
+		if !isSmallIntCell(&cells[0]) || !isSmallIntCell(&cells[len(cells)-2]) {
+			return nil
+		}
+		var addresses []string
+		for i := 1; i < len(cells)-2; i++ {
+			a := pubKeyAddress(&cells[i])
+			if a == "" {
+				return nil
+			}
+			addresses = append(addresses, a)
+		}
+		return addresses
+	}
+	return nil
+}
+
+// isP2PKHCells returns true if the cells are exactly a P2PKH locking script
+func isP2PKHCells(cells []bpu.Cell) bool {
+	if len(cells) != 5 {
+		return false
+	}
+	pkh, ok := cellData(&cells[2])
+	return isOp(&cells[0], script.OpDUP) &&
+		isOp(&cells[1], script.OpHASH160) &&
+		ok && len(pkh) == 20 &&
+		isOp(&cells[3], script.OpEQUALVERIFY) &&
+		isOp(&cells[4], script.OpCHECKSIG)
+}
+
+// findEnvelope returns the bounds of an OP_FALSE OP_IF ... OP_ENDIF
+// inscription envelope, end being the index after OP_ENDIF
+func findEnvelope(cells []bpu.Cell) (start, end int, ok bool) {
+	for i := 0; i+1 < len(cells); i++ {
+		if !isOp(&cells[i], script.OpFALSE) || !isOp(&cells[i+1], script.OpIF) {
+			continue
+		}
+		for j := i + 2; j < len(cells); j++ {
+			if isOp(&cells[j], script.OpENDIF) {
+				return i, j + 1, true
+			}
+		}
+		return 0, 0, false
+	}
+	return 0, 0, false
+}
+
+// pubKeyAddress returns the address of a cell holding a public key,
+// or an empty string if the cell is not a public key
+func pubKeyAddress(cell *bpu.Cell) string {
+	pubKey, ok := cellData(cell)
+	if !ok {
+		return ""
+	}
+	switch {
+	case len(pubKey) == 33 && (pubKey[0] == 0x02 || pubKey[0] == 0x03):
+	case len(pubKey) == 65 && pubKey[0] == 0x04:
+	default:
+		return ""
+	}
+	a, err := script.NewAddressFromPublicKeyHash(crypto.Hash160(pubKey), true)
+	if err != nil {
+		return ""
+	}
+	return a.AddressString
+}
+
+// isOp returns true if the cell is the given opcode
+func isOp(cell *bpu.Cell, op uint8) bool {
+	cellOp, ok := cellOpcode(cell)
+	return ok && cellOp == op
+}
+
+// isSmallIntCell returns true if the cell is one of OP_1 through OP_16
+func isSmallIntCell(cell *bpu.Cell) bool {
+	op, ok := cellOpcode(cell)
+	return ok && op >= script.Op1 && op <= script.Op16
+}
+
+// cellData returns the bytes pushed by a pushdata cell
+func cellData(cell *bpu.Cell) ([]byte, bool) {
+	data, err := (*Cell)(cell).Bytes()
+	return data, err == nil
+}
@@ -0,0 +1,160 @@
+package bob
+
+import (
+	"fmt"
+	"testing"
+
+	test "github.com/bitcoinschema/go-bob/testing"
+	"github.com/bsv-blockchain/go-sdk/script"
+	"github.com/bsv-blockchain/go-sdk/transaction"
+	"github.com/bsv-blockchain/go-sdk/transaction/template/p2pkh"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTx_InputAddresses tests the method InputAddresses()
+func TestTx_InputAddresses(t *testing.T) {
+	t.Parallel()
+
+	bobTx, err := NewFromString(sampleBobTx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"1FFuYLM8a66GddCG25nUbarazeMr5dnUwC"}, bobTx.InputAddresses())
+
+	// no address known
+	bobTx.In[0].E.A = nil
+	require.Empty(t, bobTx.InputAddresses())
+}
+
+// TestTx_OutputAddresses tests the method OutputAddresses()
+func TestTx_OutputAddresses(t *testing.T) {
+	t.Parallel()
+
+	t.Run("from bob", func(t *testing.T) {
+		bobTx, err := NewFromString(sampleBobTx)
+		require.NoError(t, err)
+		require.Equal(t, []string{"1FFuYLM8a66GddCG25nUbarazeMr5dnUwC"}, bobTx.OutputAddresses())
+	})
+
+	t.Run("from raw tx", func(t *testing.T) {
+		bobTx, err := NewFromRawTxString(rawBobTx)
+		require.NoError(t, err)
+		expected := []string{"1Twetcht1cTUxpdDoX5HQRpoXeuupAdyf", "15HqYP2qHH8TuV1zwzVyw8tBRfVSJ6x8vL"}
+		require.Equal(t, expected, bobTx.OutputAddresses())
+
+		// derived from the locking script when E.A is missing
+		for i := range bobTx.Out {
+			bobTx.Out[i].E.A = nil
+		}
+		require.Equal(t, expected, bobTx.OutputAddresses())
+	})
+}
+
+// TestTx_AddressesByOutput tests the method AddressesByOutput() for all supported script types
+func TestTx_AddressesByOutput(t *testing.T) {
+	t.Parallel()
+
+	key1, key2, key3 := test.Key(1), test.Key(2), test.Key(3)
+	address1, address2, address3 := test.Address(key1), test.Address(key2), test.Address(key3)
+
+	tx := transaction.NewTransaction()
+
+	// 0: P2PKH
+	p2pkhScript, err := p2pkh.Lock(address1)
+	require.NoError(t, err)
+	tx.AddOutput(&transaction.TransactionOutput{LockingScript: p2pkhScript, Satoshis: 1})
+
+	// 1: P2PK (compressed)
+	p2pk := script.NewFromBytes([]byte{})
+	_ = p2pk.AppendPushData(key2.PubKey().Compressed())
+	_ = p2pk.AppendOpcodes(script.OpCHECKSIG)
+	tx.AddOutput(&transaction.TransactionOutput{LockingScript: p2pk, Satoshis: 1})
+
+	// 2: bare multisig 2 of 3
+	multisig := script.NewFromBytes([]byte{})
+	_ = multisig.AppendOpcodes(script.Op2)
+	_ = multisig.AppendPushData(key1.PubKey().Compressed())
+	_ = multisig.AppendPushData(key2.PubKey().Compressed())
+	_ = multisig.AppendPushData(key3.PubKey().Compressed())
+	_ = multisig.AppendOpcodes(script.Op3, script.OpCHECKMULTISIG)
+	tx.AddOutput(&transaction.TransactionOutput{LockingScript: multisig, Satoshis: 1})
+
+	// 3: OP_RETURN
+	data := script.NewFromBytes([]byte{})
+	_ = data.AppendOpcodes(script.OpFALSE, script.OpRETURN)
+	_ = data.AppendPushDataString("data")
+	tx.AddOutput(&transaction.TransactionOutput{LockingScript: data})
+
+	// 4: P2PKH followed by an inscription and OP_RETURN data
+	ordAfter := script.NewFromBytes(append([]byte{}, *p2pkhScript...))
+	test.AppendInscription(ordAfter, "text/plain", []byte("hello"))
+	_ = ordAfter.AppendOpcodes(script.OpRETURN)
+	_ = ordAfter.AppendPushDataString("1PuQa7K62MiKCtssSLKy1kh56WWU7MtUR5")
+	tx.AddOutput(&transaction.TransactionOutput{LockingScript: ordAfter, Satoshis: 1})
+
+	// 5: inscription followed by P2PKH
+	ordBefore := script.NewFromBytes([]byte{})
+	test.AppendInscription(ordBefore, "text/plain", []byte("hello"))
+	p2pkhScript3, err := p2pkh.Lock(address3)
+	require.NoError(t, err)
+	*ordBefore = append(*ordBefore, *p2pkhScript3...)
+	tx.AddOutput(&transaction.TransactionOutput{LockingScript: ordBefore, Satoshis: 1})
+
+	// 6: P2PK (uncompressed)
+	p2pkUncompressed := script.NewFromBytes([]byte{})
+	_ = p2pkUncompressed.AppendPushData(key3.PubKey().Uncompressed())
+	_ = p2pkUncompressed.AppendOpcodes(script.OpCHECKSIG)
+	tx.AddOutput(&transaction.TransactionOutput{LockingScript: p2pkUncompressed, Satoshis: 1})
+
+	// 7: inscription followed by P2PKH and OP_RETURN data
+	ordData := script.NewFromBytes(append([]byte{}, *ordBefore...))
+	_ = ordData.AppendOpcodes(script.OpRETURN)
+	_ = ordData.AppendPushDataString("1PuQa7K62MiKCtssSLKy1kh56WWU7MtUR5")
+	tx.AddOutput(&transaction.TransactionOutput{LockingScript: ordData, Satoshis: 1})
+
+	bobTx, err := NewFromRawTxString(tx.String())
+	require.NoError(t, err)
+
+	uncompressed, err := script.NewAddressFromPublicKeyWithCompression(key3.PubKey(), true, false)
+	require.NoError(t, err)
+
+	expected := map[uint32][]string{
+		0: {address1.AddressString},
+		1: {address2.AddressString},
+		2: {address1.AddressString, address2.AddressString, address3.AddressString},
+		4: {address1.AddressString},
+		5: {address3.AddressString},
+		6: {uncompressed.AddressString},
+		7: {address3.AddressString},
+	}
+	require.Equal(t, expected, bobTx.AddressesByOutput())
+	require.Equal(t, []string{
+		address1.AddressString,
+		address2.AddressString,
+		address1.AddressString, address2.AddressString, address3.AddressString,
+		address1.AddressString,
+		address3.AddressString,
+		uncompressed.AddressString,
+		address3.AddressString,
+	}, bobTx.OutputAddresses())
+}
+
+// ExampleTx_OutputAddresses example using OutputAddresses()
+func ExampleTx_OutputAddresses() {
+	b, err := NewFromRawTxString(rawBobTx)
+	if err != nil {
+		fmt.Printf("error occurred: %s", err.Error())
+		return
+	}
+	fmt.Printf("found addresses: %v", b.OutputAddresses())
+	// Output:found addresses: [1Twetcht1cTUxpdDoX5HQRpoXeuupAdyf 15HqYP2qHH8TuV1zwzVyw8tBRfVSJ6x8vL]
+}
+
+// BenchmarkTx_AddressesByOutput benchmarks the method AddressesByOutput()
+func BenchmarkTx_AddressesByOutput(b *testing.B) {
+	bobTx, _ := NewFromRawTxString(rawBobTx)
+	for i := range bobTx.Out {
+		bobTx.Out[i].E.A = nil
+	}
+	for i := 0; i < b.N; i++ {
+		_ = bobTx.AddressesByOutput()
+	}
+}
@@ -13,7 +13,6 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"strings"
 
 	"github.com/bitcoinschema/go-bpu"
 	"github.com/bsv-blockchain/go-sdk/chainhash"
@@ -38,10 +37,35 @@ type Tx struct {
 	bpu.Tx
 }
 
-// used by bpu.Parse to determine if the parsing should be shallow or deep
-// always using shallow since it covers 99.99% of cases and eliminates
-// bottlenecking on txs with lots of pushdatas (like complex sCrypt contracts)
-var shallowMode = bpu.Shallow
+// Mode determines if the parsing should be shallow or deep
+type Mode = bpu.Mode
+
+// Parse modes
+const (
+	// ModeShallow only evaluates the first and last 128 pushdatas of scripts
+	// with more than 255 pushdatas
+	ModeShallow = bpu.Shallow
+
+	// ModeDeep evaluates every pushdata regardless of quantity
+	ModeDeep = bpu.Deep
+)
+
+// ParseOptions configures how a transaction is parsed into BOB format
+//
+// The zero value gives the same result as FromRawTxString and FromTx
+type ParseOptions struct {
+	// Mode defaults to ModeShallow since it covers 99.99% of cases and eliminates
+	// bottlenecking on txs with lots of pushdatas (like complex sCrypt contracts)
+	Mode Mode
+
+	// SplitConfig determines where scripts are split into tapes,
+	// defaults to DefaultSplitConfig
+	SplitConfig []bpu.SplitConfig
+
+	// SkipInputs leaves the input tapes empty, only the outpoint
+	// and sequence of each input are set
+	SkipInputs bool
+}
 
 // NewFromBytes creates a new BOB Tx from a NDJSON line representing a BOB transaction,
 // as returned by the bitbus 2 API
@@ -75,6 +99,25 @@ func NewFromTx(tx *transaction.Transaction) (bobTx *Tx, err error) {
 	return
 }
 
+// NewFromRawTxStringWithOptions creates a new BobTx from a hex encoded raw tx string
+// using the given parse options
+func NewFromRawTxStringWithOptions(rawTxString string, opts ParseOptions) (bobTx *Tx, err error) {
+	bobTx = new(Tx)
+	err = bobTx.FromRawTxStringWithOptions(rawTxString, opts)
+	return
+}
+
+// NewFromTxWithOptions creates a new BobTx from a libsv Transaction
+// using the given parse options
+func NewFromTxWithOptions(tx *transaction.Transaction, opts ParseOptions) (bobTx *Tx, err error) {
+	bobTx = new(Tx)
+	err = bobTx.FromTxWithOptions(tx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return
+}
+
 // FromBytes takes a BOB formatted tx string as bytes
 func (t *Tx) FromBytes(line []byte) error {
 	tu := new(bpu.Tx)
@@ -147,39 +190,13 @@ func (t *Tx) FromBytes(line []byte) error {
 
 // FromRawTxString takes a hex encoded tx string
 func (t *Tx) FromRawTxString(rawTxString string) (err error) {
+	return t.FromRawTxStringWithOptions(rawTxString, ParseOptions{})
+}
 
-	var separator = "|"
-	var l = bpu.IncludeL
-	var opReturn = uint8(106)
-	var opFalse = uint8(0)
-	var splitConfig = []bpu.SplitConfig{
-		{
-			Token: &bpu.Token{
-				Op: &opReturn,
-			},
-			Include: &l,
-		},
-		{
-			Token: &bpu.Token{
-				Op: &opFalse,
-			},
-			Include: &l,
-			Require: &opReturn,
-		},
-		{
-			Token: &bpu.Token{
-				S: &separator,
-			},
-			Require: &opReturn,
-		},
-	}
-
-	bpuTx, err := bpu.Parse(bpu.ParseConfig{RawTxHex: &rawTxString, SplitConfig: splitConfig, Mode: &shallowMode})
-	if bpuTx != nil {
-		t.Tx = *bpuTx
-	}
-
-	return
+// FromRawTxStringWithOptions takes a hex encoded tx string and parses it
+// using the given parse options
+func (t *Tx) FromRawTxStringWithOptions(rawTxString string, opts ParseOptions) (err error) {
+	return t.parse(bpu.ParseConfig{RawTxHex: &rawTxString}, opts)
 }
 
 // FromString takes a BOB formatted string
@@ -190,38 +207,75 @@ func (t *Tx) FromString(line string) (err error) {
 
 // FromTx takes a bt.Tx
 func (t *Tx) FromTx(tx *transaction.Transaction) error {
+	return t.FromTxWithOptions(tx, ParseOptions{})
+}
+
+// FromTxWithOptions takes a bt.Tx and parses it using the given parse options
+func (t *Tx) FromTxWithOptions(tx *transaction.Transaction, opts ParseOptions) error {
 
 	if tx == nil {
 		return fmt.Errorf("Tx must be set")
 	}
-	var separator = "|"
-	var l = bpu.IncludeL
-	var opReturn = uint8(106)
-	var splitConfig = []bpu.SplitConfig{
-		{
-			Token: &bpu.Token{
-				Op: &opReturn,
-			},
-			Include: &l,
-		},
-		{
-			Token: &bpu.Token{
-				S: &separator,
-			},
-			Require: &opReturn,
-		},
+	return t.parse(bpu.ParseConfig{Tx: tx}, opts)
+}
+
+// parse runs bpu.Parse with the given parse options applied to the config
+func (t *Tx) parse(config bpu.ParseConfig, opts ParseOptions) error {
+
+	// every call gets its own mode so concurrent calls never share state
+	mode := opts.Mode
+	if mode == "" {
+		mode = ModeShallow
+	}
+	config.Mode = &mode
+	config.SplitConfig = opts.SplitConfig
+	if config.SplitConfig == nil {
+		config.SplitConfig = DefaultSplitConfig()
 	}
 
-	bpuTx, err := bpu.Parse(bpu.ParseConfig{Tx: tx, SplitConfig: splitConfig, Mode: &shallowMode})
+	tx := config.Tx
+	if tx == nil {
+		if config.RawTxHex == nil || len(*config.RawTxHex) == 0 {
+			return fmt.Errorf("raw tx must be set")
+		}
+		var err error
+		if tx, err = transaction.NewTransactionFromHex(*config.RawTxHex); err != nil {
+			return fmt.Errorf("failed to parse tx: %w", err)
+		}
+	}
+	config.Tx = tx
+	config.RawTxHex = nil
+	if opts.SkipInputs {
+		config.Tx = withoutUnlockingScripts(tx)
+	}
+
+	bpuTx, err := bpu.Parse(config)
 	if err != nil {
 		return err
 	}
 	if bpuTx != nil {
 		t.Tx = *bpuTx
+		if opts.SkipInputs {
+			// the txid of the stripped copy differs from the original
+			t.Tx.Tx.H = tx.TxID().String()
+		}
 	}
 	return nil
 }
 
+// withoutUnlockingScripts returns a shallow copy of the tx where
+// the inputs have no unlocking scripts to parse
+func withoutUnlockingScripts(tx *transaction.Transaction) *transaction.Transaction {
+	stripped := *tx
+	stripped.Inputs = make([]*transaction.TransactionInput, len(tx.Inputs))
+	for i, in := range tx.Inputs {
+		input := *in
+		input.UnlockingScript = nil
+		stripped.Inputs[i] = &input
+	}
+	return &stripped
+}
+
 // ToRawTxString converts the BOBTx to a libsv.transaction, and outputs the raw hex
 func (t *Tx) ToRawTxString() (string, error) {
 	tx, err := t.ToTx()
@@ -239,82 +293,122 @@ func (t *Tx) ToString() (string, error) {
 
 }
 
+// SourceOutputLookup returns the output spent by the input spending txid:vout,
+// or nil if it is not known
+type SourceOutputLookup func(txid string, vout uint32) (*transaction.TransactionOutput, error)
+
+// ToTxOptions configures how a BOB tx is converted by ToTxWithOptions
+type ToTxOptions struct {
+	// SourceOutputs looks up the outputs spent by the inputs. Inputs it does
+	// not know (and all inputs when not set) with a known address are
+	// assumed to spend a P2PKH output of the address for E.V satoshis.
+	SourceOutputs SourceOutputLookup
+
+	// Original is the tx the BOB tx was parsed from, if it is known. Its
+	// scripts tell what the tapes do not: the chunks left out of them
+	// (split tokens excluded by the split rules, chunks dropped by shallow
+	// parsing) and the push opcodes of non-minimal pushdatas, so the scripts
+	// are rebuilt byte for byte. Scripts whose cells no longer match the
+	// original chunks at their index (II) are rebuilt from their tapes only.
+	Original *transaction.Transaction
+}
+
 // ToTx returns a bt.Tx
 func (t *Tx) ToTx() (*transaction.Transaction, error) {
+	return t.ToTxWithOptions(ToTxOptions{})
+}
+
+// ToTxWithOptions returns a bt.Tx using the given options
+func (t *Tx) ToTxWithOptions(opts ToTxOptions) (*transaction.Transaction, error) {
 	tx := transaction.NewTransaction()
 
 	tx.LockTime = t.Lock
 
-	for _, in := range t.In {
-
-		if len(in.Tape) == 0 || len(in.Tape[0].Cell) == 0 {
-			return nil, fmt.Errorf("failed to process inputs. More tapes or cells than expected. %+v", in.Tape)
-		}
+	for inIdx := range t.In {
+		in := &t.In[inIdx]
 
-		add, err := script.NewAddressFromString(*in.E.A)
+		unlockingScript, _, err := tapesToScript(in.Tape, newScriptInfo(in.Tape, inputScript(opts.Original, inIdx)))
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("failed to rebuild unlocking script of input %d: %w", inIdx, err)
 		}
-		prevTxScript, _ := p2pkh.Lock(add)
 
-		var scriptAsm []string
-		// TODO: This will break if there is ever a bpu splitter present in inputs
-		for _, cell := range in.Tape[0].Cell {
-			cellData := *cell.H
-			scriptAsm = append(scriptAsm, cellData)
+		if in.E.H == nil {
+			return nil, fmt.Errorf("input %d is missing the source txid", inIdx)
 		}
-
-		builtUnlockScript, err := script.NewFromASM(strings.Join(scriptAsm, " "))
+		sourceTxid, err := chainhash.NewHashFromHex(*in.E.H)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get script from asm: %v error: %w", scriptAsm, err)
-		}
-		v := uint64(0)
-		if in.E.V != nil {
-			v = *in.E.V
+			return nil, fmt.Errorf("input %d has an invalid source txid: %w", inIdx, err)
 		}
 
 		// add inputs
 		i := &transaction.TransactionInput{
-			SourceTxOutIndex: in.E.I, // TODO: This might be getting set incorrectly?
-			UnlockingScript:  builtUnlockScript,
+			SourceTXID:       sourceTxid,
+			SourceTxOutIndex: in.E.I,
+			UnlockingScript:  unlockingScript,
 			SequenceNumber:   in.Seq,
 		}
-		i.SourceTXID, _ = chainhash.NewHashFromHex(*in.E.H)
-		i.SetSourceTxOutput(&transaction.TransactionOutput{
-			Satoshis:      v,
-			LockingScript: prevTxScript,
-		})
+
+		sourceOutput, err := inputSourceOutput(in, opts.SourceOutputs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get source output of input %d: %w", inIdx, err)
+		}
+		if sourceOutput != nil {
+			i.SetSourceTxOutput(sourceOutput)
+		}
 
 		tx.Inputs = append(tx.Inputs, i) // AddInput(i)
 	}
 
 	// add outputs
-	for _, out := range t.Out {
-		// Build the locking script
-		var lockScriptAsm []string
-		for tapeIdx, tape := range out.Tape {
-			for cellIdx, cell := range tape.Cell {
-				if cellIdx == 0 && tapeIdx > 1 {
-					// add the separator back in
-					lockScriptAsm = append(lockScriptAsm, ProtocolDelimiterAsm)
-				}
+	for outIdx := range t.Out {
+		out := &t.Out[outIdx]
 
-				if cell.H != nil {
-					lockScriptAsm = append(lockScriptAsm, *cell.H)
-				} else if cell.Ops != nil {
-					lockScriptAsm = append(lockScriptAsm, *cell.Ops)
-				}
-			}
+		// Build the locking script
+		lockingScript, _, err := tapesToScript(out.Tape, newScriptInfo(out.Tape, outputScript(opts.Original, outIdx)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to rebuild locking script of output %d: %w", outIdx, err)
 		}
 
-		lockingScript, _ := script.NewFromASM(strings.Join(lockScriptAsm, " "))
 		o := &transaction.TransactionOutput{
-			Satoshis:      *out.E.V,
 			LockingScript: lockingScript,
 		}
+		if out.E.V != nil {
+			o.Satoshis = *out.E.V
+		}
 
 		tx.AddOutput(o)
 	}
 
 	return tx, nil
 }
+
+// inputSourceOutput returns the output spent by the input, or nil if it is not known
+func inputSourceOutput(in *bpu.Input, lookup SourceOutputLookup) (*transaction.TransactionOutput, error) {
+	if lookup != nil {
+		sourceOutput, err := lookup(*in.E.H, in.E.I)
+		if err != nil || sourceOutput != nil {
+			return sourceOutput, err
+		}
+	}
+
+	if !isAddress(in.E.A) {
+		return nil, nil
+	}
+	add, err := script.NewAddressFromString(*in.E.A)
+	if err != nil {
+		return nil, err
+	}
+	prevTxScript, err := p2pkh.Lock(add)
+	if err != nil {
+		return nil, err
+	}
+
+	v := uint64(0)
+	if in.E.V != nil {
+		v = *in.E.V
+	}
+	return &transaction.TransactionOutput{
+		Satoshis:      v,
+		LockingScript: prevTxScript,
+	}, nil
+}
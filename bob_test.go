@@ -1,15 +1,21 @@
 package bob
 
 import (
+	"bytes"
 	"encoding/hex"
 	"fmt"
 	"log"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 
 	test "github.com/bitcoinschema/go-bob/testing"
+	"github.com/bitcoinschema/go-bpu"
+	"github.com/bsv-blockchain/go-sdk/chainhash"
 	"github.com/bsv-blockchain/go-sdk/script"
 	"github.com/bsv-blockchain/go-sdk/transaction"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -106,15 +112,16 @@ func BenchmarkNewFromBytes(b *testing.B) {
 	}
 }
 
-// TestNewFromBytesPanic tests for nil case in NewFromBytes()
-func TestNewFromBytesPanic(t *testing.T) {
+// TestNewFromBytesBadStrings tests that invalid cell data returns an error instead of panicking
+func TestNewFromBytesBadStrings(t *testing.T) {
 	t.Parallel()
 
-	require.Panics(t, func() {
+	require.NotPanics(t, func() {
 		b, err := NewFromBytes([]byte(sampleBobTxBadStrings))
 		require.NoError(t, err)
 		require.NotNil(t, b)
-		_, _ = b.ToRawTxString()
+		_, err = b.ToRawTxString()
+		require.Error(t, err)
 	})
 }
 
@@ -415,6 +422,115 @@ func BenchmarkNewFromTx(b *testing.B) {
 	}
 }
 
+// testManyPushesTx returns a tx with an OP_RETURN output of more than 255 pushdatas
+func testManyPushesTx() *transaction.Transaction {
+	tx := transaction.NewTransaction()
+	s := script.NewFromBytes([]byte{})
+	_ = s.AppendOpcodes(script.OpFALSE, script.OpRETURN)
+	for i := 0; i < 300; i++ {
+		_ = s.AppendPushData([]byte(fmt.Sprintf("data %d", i)))
+	}
+
+	tx.AddOutput(&transaction.TransactionOutput{
+		LockingScript: s,
+		Satoshis:      0,
+	})
+	return tx
+}
+
+// TestNewFromTxWithOptions tests the parse modes of NewFromTxWithOptions()
+func TestNewFromTxWithOptions(t *testing.T) {
+	t.Parallel()
+
+	var (
+		// Testing parse modes
+		tests = []struct {
+			mode          Mode
+			expectedCells int
+		}{
+			{"", 254},
+			{ModeShallow, 254},
+			{ModeDeep, 300},
+		}
+	)
+
+	tx := testManyPushesTx()
+	for _, test := range tests {
+		b, err := NewFromTxWithOptions(tx, ParseOptions{Mode: test.mode})
+		require.NoError(t, err)
+		require.Len(t, b.Out[0].Tape, 2)
+		require.Len(t, b.Out[0].Tape[1].Cell, test.expectedCells)
+		require.Equal(t, tx.TxID().String(), b.Tx.Tx.H)
+	}
+
+	b, err := NewFromTxWithOptions(nil, ParseOptions{})
+	require.Error(t, err)
+	require.Nil(t, b)
+}
+
+// TestNewFromRawTxStringWithOptions tests NewFromRawTxStringWithOptions()
+func TestNewFromRawTxStringWithOptions(t *testing.T) {
+	t.Parallel()
+
+	t.Run("deep mode", func(t *testing.T) {
+		b, err := NewFromRawTxStringWithOptions(testManyPushesTx().String(), ParseOptions{Mode: ModeDeep})
+		require.NoError(t, err)
+		require.Len(t, b.Out[0].Tape[1].Cell, 300)
+	})
+
+	t.Run("skip inputs", func(t *testing.T) {
+		full, err := NewFromRawTxString(rawBobTx)
+		require.NoError(t, err)
+
+		b, err := NewFromRawTxStringWithOptions(rawBobTx, ParseOptions{SkipInputs: true})
+		require.NoError(t, err)
+		require.Equal(t, full.Tx.Tx.H, b.Tx.Tx.H)
+		require.Len(t, b.In, 1)
+		require.Empty(t, b.In[0].Tape)
+		require.Equal(t, full.In[0].E.H, b.In[0].E.H)
+		require.Equal(t, full.In[0].E.I, b.In[0].E.I)
+		require.Equal(t, full.In[0].Seq, b.In[0].Seq)
+		require.Equal(t, full.Out, b.Out)
+	})
+
+	t.Run("custom split config", func(t *testing.T) {
+		b, err := NewFromRawTxStringWithOptions(rawBobTx, ParseOptions{SplitConfig: []bpu.SplitConfig{}})
+		require.NoError(t, err)
+		require.Len(t, b.Out[0].Tape, 1)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := NewFromRawTxStringWithOptions("", ParseOptions{SkipInputs: true})
+		require.Error(t, err)
+		_, err = NewFromRawTxStringWithOptions("invalid-tx", ParseOptions{SkipInputs: true})
+		require.Error(t, err)
+	})
+}
+
+// TestParseOptions_Concurrent tests that concurrent calls using different modes do not interfere
+func TestParseOptions_Concurrent(t *testing.T) {
+	t.Parallel()
+
+	tx := testManyPushesTx()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		mode, expected := ModeShallow, 254
+		if i%2 == 0 {
+			mode, expected = ModeDeep, 300
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			b, err := NewFromTxWithOptions(tx, ParseOptions{Mode: mode})
+			if assert.NoError(t, err) {
+				assert.Len(t, b.Out[0].Tape[1].Cell, expected)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
 // TestNewFromTx2 tests for nil case in NewFromTx()
 func TestNewFromTx2(t *testing.T) {
 	t.Parallel()
@@ -459,6 +575,153 @@ func TestTx_ToTx(t *testing.T) {
 	require.Equal(t, bobTx.Tx.Tx.H, tx.TxID().String())
 }
 
+// TestTx_ToRawTxString_RoundTrip tests that every fixture survives a round trip through BOB
+func TestTx_ToRawTxString_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	files, err := filepath.Glob("./testing/tx/*.hex")
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+
+	for _, file := range files {
+		rawTx := test.GetTestHex(file)
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			bobTx, err := NewFromRawTxString(rawTx)
+			require.NoError(t, err)
+
+			var roundTrip string
+			roundTrip, err = bobTx.ToRawTxString()
+			require.NoError(t, err)
+			require.Equal(t, rawTx, roundTrip)
+
+			// and through the BOB json
+			var bobJSON string
+			bobJSON, err = bobTx.ToString()
+			require.NoError(t, err)
+
+			var fromJSON *Tx
+			fromJSON, err = NewFromString(bobJSON)
+			require.NoError(t, err)
+
+			roundTrip, err = fromJSON.ToRawTxString()
+			require.NoError(t, err)
+			require.Equal(t, rawTx, roundTrip)
+		})
+	}
+}
+
+// testSpendingTx returns a tx spending a bare multisig output, a custom script and a P2PKH output
+func testSpendingTx(t *testing.T) *transaction.Transaction {
+	tx := transaction.NewTransaction()
+	sourceTxid, err := chainhash.NewHashFromHex(testTxID)
+	require.NoError(t, err)
+
+	// bare multisig: OP_0 <sig> <sig>
+	multisig := script.NewFromBytes([]byte{})
+	_ = multisig.AppendOpcodes(script.Op0)
+	_ = multisig.AppendPushData(bytes.Repeat([]byte{0x30}, 71))
+	_ = multisig.AppendPushData(bytes.Repeat([]byte{0x31}, 72))
+
+	// custom script: <preimage> OP_1 OP_TRUE OP_16
+	custom := script.NewFromBytes([]byte{})
+	_ = custom.AppendPushData([]byte("some preimage"))
+	_ = custom.AppendOpcodes(script.Op1, script.OpTRUE, script.Op16)
+
+	// P2PKH: <sig> <pubkey>
+	p2pkhUnlock := script.NewFromBytes([]byte{})
+	_ = p2pkhUnlock.AppendPushData(bytes.Repeat([]byte{0x30}, 71))
+	_ = p2pkhUnlock.AppendPushData(test.Key(1).PubKey().Compressed())
+
+	for i, unlockingScript := range []*script.Script{multisig, custom, p2pkhUnlock, script.NewFromBytes([]byte{})} {
+		tx.AddInput(&transaction.TransactionInput{
+			SourceTXID:       sourceTxid,
+			SourceTxOutIndex: uint32(i),
+			UnlockingScript:  unlockingScript,
+			SequenceNumber:   0xfffffffe,
+		})
+	}
+	tx.AddOutput(&transaction.TransactionOutput{LockingScript: testExampleTx().Outputs[0].LockingScript})
+	tx.LockTime = 800000
+	return tx
+}
+
+const testTxID = "9ec47d91ff11edb62f337dc828c52e39072d1a5a2f1b180bbfae9c3279d81a7c"
+
+// TestTx_ToTx_Inputs tests that non-P2PKH inputs survive a round trip through BOB
+func TestTx_ToTx_Inputs(t *testing.T) {
+	t.Parallel()
+
+	tx := testSpendingTx(t)
+	bobTx, err := NewFromTx(tx)
+	require.NoError(t, err)
+
+	var rawTx string
+	rawTx, err = bobTx.ToRawTxString()
+	require.NoError(t, err)
+	require.Equal(t, tx.String(), rawTx)
+
+	// no address or value known
+	var rebuilt *transaction.Transaction
+	rebuilt, err = bobTx.ToTx()
+	require.NoError(t, err)
+	require.Nil(t, rebuilt.Inputs[0].SourceTxOutput())
+	require.Nil(t, rebuilt.Inputs[1].SourceTxOutput())
+	require.NotNil(t, rebuilt.Inputs[2].SourceTxOutput())
+	require.True(t, rebuilt.Inputs[2].SourceTxOutput().LockingScript.IsP2PKH())
+
+	bobTx.In[0].E.A = nil
+	bobTx.In[1].E.A = new(string)
+	falseStr := "false"
+	bobTx.In[3].E.A = &falseStr
+	rawTx, err = bobTx.ToRawTxString()
+	require.NoError(t, err)
+	require.Equal(t, tx.String(), rawTx)
+
+	// missing source txid
+	bobTx.In[0].E.H = nil
+	_, err = bobTx.ToTx()
+	require.Error(t, err)
+}
+
+// TestTx_ToTxWithOptions tests supplying the source outputs with a lookup function
+func TestTx_ToTxWithOptions(t *testing.T) {
+	t.Parallel()
+
+	bobTx, err := NewFromTx(testSpendingTx(t))
+	require.NoError(t, err)
+
+	multisigLock := script.NewFromBytes([]byte{})
+	_ = multisigLock.AppendOpcodes(script.Op2)
+	_ = multisigLock.AppendPushData(test.Key(1).PubKey().Compressed())
+	_ = multisigLock.AppendPushData(test.Key(2).PubKey().Compressed())
+	_ = multisigLock.AppendOpcodes(script.Op2, script.OpCHECKMULTISIG)
+
+	var lookups []uint32
+	lookup := func(txid string, vout uint32) (*transaction.TransactionOutput, error) {
+		require.Equal(t, testTxID, txid)
+		lookups = append(lookups, vout)
+		if vout == 0 {
+			return &transaction.TransactionOutput{Satoshis: 1000, LockingScript: multisigLock}, nil
+		}
+		return nil, nil
+	}
+
+	var tx *transaction.Transaction
+	tx, err = bobTx.ToTxWithOptions(ToTxOptions{SourceOutputs: lookup})
+	require.NoError(t, err)
+	require.Equal(t, []uint32{0, 1, 2, 3}, lookups)
+	require.Equal(t, uint64(1000), *tx.Inputs[0].SourceTxSatoshis())
+	require.Equal(t, multisigLock, tx.Inputs[0].SourceTxOutput().LockingScript)
+	require.Nil(t, tx.Inputs[1].SourceTxOutput())
+	require.True(t, tx.Inputs[2].SourceTxOutput().LockingScript.IsP2PKH())
+
+	// lookup errors are returned
+	_, err = bobTx.ToTxWithOptions(ToTxOptions{SourceOutputs: func(string, uint32) (*transaction.TransactionOutput, error) {
+		return nil, fmt.Errorf("not found")
+	}})
+	require.Error(t, err)
+}
+
 // ExampleTx_ToTx example using ToTx()
 func ExampleTx_ToTx() {
 	// Use an example TX
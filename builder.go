@@ -0,0 +1,205 @@
+package bob
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/bsv-blockchain/go-sdk/script"
+	"github.com/bsv-blockchain/go-sdk/transaction"
+	"github.com/bsv-blockchain/go-sdk/transaction/template/p2pkh"
+)
+
+// Builder builds a BOB transaction from scratch
+//
+// Methods can be chained, the first error being returned by Transaction and
+// Tx. Each OP_RETURN output is made of tapes separated by the protocol
+// delimiter, a tape being a list of pushdatas.
+type Builder struct {
+	err     error
+	inputs  []*transaction.UTXO
+	outputs []*builderOutput
+}
+
+// builderOutput is an output being built, either a locking script or the tapes
+// of an OP_RETURN output
+type builderOutput struct {
+	lockingScript *script.Script
+	satoshis      uint64
+	tapes         [][][]byte
+}
+
+// NewBuilder returns an empty builder
+func NewBuilder() *Builder {
+	return &Builder{}
+}
+
+// AddInput adds an input spending the utxo
+//
+// The UnlockingScriptTemplate of the utxo, if any, is used when signing.
+func (b *Builder) AddInput(utxo *transaction.UTXO) *Builder {
+	switch {
+	case b.err != nil:
+	case utxo == nil:
+		b.err = fmt.Errorf("input %d: utxo must be set", len(b.inputs))
+	case utxo.TxID == nil:
+		b.err = fmt.Errorf("input %d: utxo is missing the txid", len(b.inputs))
+	case utxo.LockingScript == nil:
+		b.err = fmt.Errorf("input %d: utxo is missing the locking script", len(b.inputs))
+	default:
+		b.inputs = append(b.inputs, utxo)
+	}
+	return b
+}
+
+// AddP2PKHOutput adds an output paying the satoshis to the address
+func (b *Builder) AddP2PKHOutput(address string, satoshis uint64) *Builder {
+	if b.err != nil {
+		return b
+	}
+	if satoshis == 0 {
+		b.err = fmt.Errorf("output %d: satoshis must be greater than 0", len(b.outputs))
+		return b
+	}
+	a, err := script.NewAddressFromString(address)
+	if err != nil {
+		b.err = fmt.Errorf("output %d: invalid address %q: %w", len(b.outputs), address, err)
+		return b
+	}
+	var s *script.Script
+	if s, err = p2pkh.Lock(a); err != nil {
+		b.err = fmt.Errorf("output %d: %w", len(b.outputs), err)
+		return b
+	}
+	b.outputs = append(b.outputs, &builderOutput{lockingScript: s, satoshis: satoshis})
+	return b
+}
+
+// AddOpReturn adds an OP_FALSE OP_RETURN output made of the tapes
+func (b *Builder) AddOpReturn(tapes ...[][]byte) *Builder {
+	if b.err != nil {
+		return b
+	}
+	out := &builderOutput{}
+	b.outputs = append(b.outputs, out)
+	for _, tape := range tapes {
+		if b.addTape(out, tape); b.err != nil {
+			break
+		}
+	}
+	return b
+}
+
+// AddTape adds a tape made of the prefix and fields to the last OP_RETURN
+// output, adding one if the last output is not an OP_RETURN output
+func (b *Builder) AddTape(prefix string, fields ...[]byte) *Builder {
+	if b.err != nil {
+		return b
+	}
+	if len(b.outputs) == 0 || b.outputs[len(b.outputs)-1].lockingScript != nil {
+		b.outputs = append(b.outputs, &builderOutput{})
+	}
+	b.addTape(b.outputs[len(b.outputs)-1], append([][]byte{[]byte(prefix)}, fields...))
+	return b
+}
+
+// addTape validates the tape and adds it to the OP_RETURN output
+//
+// Empty pushdatas (OP_0) and the protocol delimiter would split the tape
+// when parsed, so they are rejected.
+func (b *Builder) addTape(out *builderOutput, tape [][]byte) {
+	if len(tape) == 0 {
+		b.err = fmt.Errorf("output %d tape %d: tape is empty", len(b.outputs)-1, len(out.tapes))
+		return
+	}
+	for i, field := range tape {
+		switch {
+		case len(field) == 0:
+			b.err = fmt.Errorf("output %d tape %d: field %d is empty", len(b.outputs)-1, len(out.tapes), i)
+			return
+		case bytes.Equal(field, []byte(ProtocolDelimiter)):
+			b.err = fmt.Errorf("output %d tape %d: field %d is the protocol delimiter", len(b.outputs)-1, len(out.tapes), i)
+			return
+		}
+	}
+	out.tapes = append(out.tapes, tape)
+}
+
+// Transaction returns the unsigned transaction, the source output of every
+// input being set so it is ready for signing
+func (b *Builder) Transaction() (*transaction.Transaction, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+
+	tx := transaction.NewTransaction()
+	for _, utxo := range b.inputs {
+		in := &transaction.TransactionInput{
+			SourceTXID:              utxo.TxID,
+			SourceTxOutIndex:        utxo.Vout,
+			SequenceNumber:          transaction.DefaultSequenceNumber,
+			UnlockingScriptTemplate: utxo.UnlockingScriptTemplate,
+		}
+		in.SetSourceTxOutput(&transaction.TransactionOutput{
+			Satoshis:      utxo.Satoshis,
+			LockingScript: utxo.LockingScript,
+		})
+		tx.AddInput(in)
+	}
+
+	for i, out := range b.outputs {
+		if out.lockingScript != nil {
+			tx.AddOutput(&transaction.TransactionOutput{LockingScript: out.lockingScript, Satoshis: out.satoshis})
+			continue
+		}
+		s := script.NewFromBytes([]byte{})
+		_ = s.AppendOpcodes(script.OpFALSE, script.OpRETURN)
+		for j, tape := range out.tapes {
+			if j > 0 {
+				_ = s.AppendPushDataString(ProtocolDelimiter)
+			}
+			for _, field := range tape {
+				if err := s.AppendPushData(field); err != nil {
+					return nil, fmt.Errorf("output %d tape %d: %w", i, j, err)
+				}
+			}
+		}
+		tx.AddOutput(&transaction.TransactionOutput{LockingScript: s})
+	}
+	return tx, nil
+}
+
+// Tx returns the unsigned transaction in BOB format
+//
+// The tapes are those of the transaction parsed with FromTx. The value and
+// address (for P2PKH utxos) of the spent outputs are set on the inputs, so
+// ToTx attaches the source outputs.
+func (b *Builder) Tx() (*Tx, error) {
+	tx, err := b.Transaction()
+	if err != nil {
+		return nil, err
+	}
+	return newFromSourcedTx(tx)
+}
+
+// newFromSourcedTx parses the tx, setting the value and address (for P2PKH
+// outputs) of the output spent by each input with a known source output
+func newFromSourcedTx(tx *transaction.Transaction) (*Tx, error) {
+	bobTx, err := NewFromTx(tx)
+	if err != nil {
+		return nil, err
+	}
+	for i, in := range tx.Inputs {
+		source := in.SourceTxOutput()
+		if source == nil {
+			continue
+		}
+		v := source.Satoshis
+		bobTx.In[i].E.V = &v
+		if source.LockingScript != nil && source.LockingScript.IsP2PKH() {
+			if a, err := source.LockingScript.Address(); err == nil {
+				bobTx.In[i].E.A = &a.AddressString
+			}
+		}
+	}
+	return bobTx, nil
+}
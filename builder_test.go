@@ -0,0 +1,179 @@
+package bob
+
+import (
+	"fmt"
+	"testing"
+
+	test "github.com/bitcoinschema/go-bob/testing"
+	"github.com/bsv-blockchain/go-sdk/chainhash"
+	"github.com/bsv-blockchain/go-sdk/script"
+	"github.com/bsv-blockchain/go-sdk/transaction"
+	"github.com/bsv-blockchain/go-sdk/transaction/template/p2pkh"
+	"github.com/stretchr/testify/require"
+)
+
+const testUTXOTxID = "6386afa223e54d4f955e44a1ef4ae5b18bbb8689dff078627a7cb842fad4f7c6"
+
+// testUTXO returns a P2PKH utxo of the address
+func testUTXO(t testing.TB, address *script.Address, satoshis uint64) *transaction.UTXO {
+	txID, err := chainhash.NewHashFromHex(testUTXOTxID)
+	require.NoError(t, err)
+	s, err := p2pkh.Lock(address)
+	require.NoError(t, err)
+	return &transaction.UTXO{TxID: txID, Vout: 1, LockingScript: s, Satoshis: satoshis}
+}
+
+// testBuilder returns a builder with an input, a B and MAP OP_RETURN output and a P2PKH output
+func testBuilder(t testing.TB) *Builder {
+	address := test.Address(test.Key(1))
+	return NewBuilder().
+		AddInput(testUTXO(t, address, 10000)).
+		AddOpReturn([][]byte{
+			[]byte("19HxigV4QyBv3tHpQVcUEQyq1pzZVdoAut"),
+			[]byte("hello world"),
+			[]byte("text/plain"),
+			[]byte("utf-8"),
+		}).
+		AddTape("1PuQa7K62MiKCtssSLKy1kh56WWU7MtUR5", []byte("SET"), []byte("app"), []byte("go-bob")).
+		AddP2PKHOutput(address.AddressString, 1000)
+}
+
+// TestBuilder tests building a tx and re-parsing it
+func TestBuilder(t *testing.T) {
+	t.Parallel()
+
+	address := test.Address(test.Key(1))
+	b := testBuilder(t)
+
+	bobTx, err := b.Tx()
+	require.NoError(t, err)
+	require.Len(t, bobTx.In, 1)
+	require.Len(t, bobTx.Out, 2)
+
+	// OP_FALSE OP_RETURN, B and MAP tapes
+	tapes := bobTx.Out[0].Tape
+	require.Len(t, tapes, 3)
+	require.Equal(t, "19HxigV4QyBv3tHpQVcUEQyq1pzZVdoAut", *tapes[1].Cell[0].S)
+	require.Equal(t, uint8(2), tapes[1].Cell[0].II)
+	require.Equal(t, uint8(3), tapes[1].Cell[3].I)
+	require.Equal(t, uint8(0), tapes[2].Cell[0].I)
+	require.Equal(t, uint8(7), tapes[2].Cell[0].II)
+	require.Equal(t, "go-bob", *tapes[2].Cell[3].S)
+	require.Equal(t, []string{address.AddressString}, bobTx.OutputAddresses())
+
+	// the spent output is known
+	require.Equal(t, uint64(10000), *bobTx.In[0].E.V)
+	require.Equal(t, address.AddressString, *bobTx.In[0].E.A)
+	require.Equal(t, testUTXOTxID, *bobTx.In[0].E.H)
+
+	// building and re-parsing yields identical tapes
+	var rawTx string
+	rawTx, err = bobTx.ToRawTxString()
+	require.NoError(t, err)
+	var parsed *Tx
+	parsed, err = NewFromRawTxString(rawTx)
+	require.NoError(t, err)
+	for i := range bobTx.Out {
+		require.Equal(t, bobTx.Out[i].Tape, parsed.Out[i].Tape)
+	}
+	for i := range bobTx.In {
+		require.Equal(t, bobTx.In[i].Tape, parsed.In[i].Tape)
+	}
+
+	// the go-sdk tx is ready for signing
+	var tx *transaction.Transaction
+	tx, err = b.Transaction()
+	require.NoError(t, err)
+	require.Equal(t, rawTx, tx.String())
+	require.NotNil(t, tx.Inputs[0].SourceTxOutput())
+	var total uint64
+	total, err = tx.TotalInputSatoshis()
+	require.NoError(t, err)
+	require.Equal(t, uint64(10000), total)
+
+	tx, err = bobTx.ToTx()
+	require.NoError(t, err)
+	require.Equal(t, uint64(10000), tx.Inputs[0].SourceTxOutput().Satoshis)
+}
+
+// TestBuilder_AddTape tests adding tapes to OP_RETURN outputs
+func TestBuilder_AddTape(t *testing.T) {
+	t.Parallel()
+
+	address := test.Address(test.Key(1))
+	bobTx, err := NewBuilder().
+		AddTape("19HxigV4QyBv3tHpQVcUEQyq1pzZVdoAut", []byte("a"), []byte("text/plain")).
+		AddTape("1PuQa7K62MiKCtssSLKy1kh56WWU7MtUR5", []byte("SET"), []byte("a"), []byte("b")).
+		AddP2PKHOutput(address.AddressString, 1).
+		AddTape("1PuQa7K62MiKCtssSLKy1kh56WWU7MtUR5", []byte("SET"), []byte("c"), []byte("d")).
+		AddOpReturn().
+		Tx()
+	require.NoError(t, err)
+	require.Len(t, bobTx.Out, 4)
+	require.Len(t, bobTx.Out[0].Tape, 3)
+	require.Len(t, bobTx.Out[2].Tape, 2)
+	require.Len(t, bobTx.Out[3].Tape, 1)
+
+	tape := bobTx.TapeByPrefix("1PuQa7K62MiKCtssSLKy1kh56WWU7MtUR5")
+	require.NotNil(t, tape)
+	require.Equal(t, "b", *tape.Cell[3].S)
+}
+
+// TestBuilder_Errors tests invalid builder input
+func TestBuilder_Errors(t *testing.T) {
+	t.Parallel()
+
+	address := test.Address(test.Key(1))
+	txID, err := chainhash.NewHashFromHex(testUTXOTxID)
+	require.NoError(t, err)
+
+	var (
+		// Testing every validation
+		tests = []struct {
+			name    string
+			builder *Builder
+		}{
+			{"nil utxo", NewBuilder().AddInput(nil)},
+			{"utxo without txid", NewBuilder().AddInput(&transaction.UTXO{LockingScript: &script.Script{}})},
+			{"utxo without script", NewBuilder().AddInput(&transaction.UTXO{TxID: txID})},
+			{"invalid address", NewBuilder().AddP2PKHOutput("1invalid", 1)},
+			{"zero satoshis", NewBuilder().AddP2PKHOutput(address.AddressString, 0)},
+			{"empty tape", NewBuilder().AddOpReturn([][]byte{})},
+			{"empty field", NewBuilder().AddTape("prefix", []byte("a"), []byte{})},
+			{"empty prefix", NewBuilder().AddTape("")},
+			{"delimiter field", NewBuilder().AddTape("prefix", []byte(ProtocolDelimiter))},
+			{"first error is kept", NewBuilder().AddInput(nil).AddP2PKHOutput(address.AddressString, 1)},
+		}
+	)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := test.builder.Transaction()
+			require.Error(t, err)
+			_, err = test.builder.Tx()
+			require.Error(t, err)
+		})
+	}
+}
+
+// ExampleBuilder example using Builder
+func ExampleBuilder() {
+	bobTx, err := NewBuilder().
+		AddTape("19HxigV4QyBv3tHpQVcUEQyq1pzZVdoAut", []byte("hello world"), []byte("text/plain"), []byte("utf-8")).
+		AddP2PKHOutput("1PuQa7K62MiKCtssSLKy1kh56WWU7MtUR5", 1000).
+		Tx()
+	if err != nil {
+		fmt.Printf("error occurred: %s", err.Error())
+		return
+	}
+	fmt.Printf("found %d outputs, %d tapes in the first", len(bobTx.Out), len(bobTx.Out[0].Tape))
+	// Output:found 2 outputs, 2 tapes in the first
+}
+
+// BenchmarkBuilder_Tx benchmarks the method Tx()
+func BenchmarkBuilder_Tx(b *testing.B) {
+	builder := testBuilder(b)
+	for i := 0; i < b.N; i++ {
+		_, _ = builder.Tx()
+	}
+}
@@ -0,0 +1,129 @@
+package bob
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// DecodeError is returned (or reported) when a line of an NDJSON stream
+// cannot be parsed into a BOB transaction
+type DecodeError struct {
+	Line int
+	Err  error
+}
+
+// Error returns the error message including the offending line number
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+// Unwrap returns the underlying parse error
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// DecoderOptions configures the behavior of a Decoder
+type DecoderOptions struct {
+	// SkipInvalid continues with the next line instead of stopping
+	// when a line fails to parse
+	SkipInvalid bool
+
+	// OnInvalid is called for every line skipped because of SkipInvalid
+	OnInvalid func(err *DecodeError)
+}
+
+// Decoder reads newline delimited BOB transactions (NDJSON) from a stream,
+// such as a Bitbus or Bitsocket feed, one transaction at a time
+//
+// Lines may be of any length. Blank lines are ignored.
+type Decoder struct {
+	opts DecoderOptions
+	r    *bufio.Reader
+	buf  []byte
+	tx   *Tx
+	err  error
+	line int
+}
+
+// NewDecoder creates a new Decoder reading from r
+func NewDecoder(r io.Reader) *Decoder {
+	return NewDecoderWithOptions(r, DecoderOptions{})
+}
+
+// NewDecoderWithOptions creates a new Decoder reading from r using the given options
+func NewDecoderWithOptions(r io.Reader, opts DecoderOptions) *Decoder {
+	return &Decoder{
+		opts: opts,
+		r:    bufio.NewReader(r),
+	}
+}
+
+// Next advances the decoder to the next transaction, which is then
+// available through Tx. It returns false when the stream is exhausted
+// or an error occurred, in which case Err reports the error.
+func (d *Decoder) Next() bool {
+	d.tx = nil
+	for d.err == nil {
+		line, err := d.readLine()
+		if err != nil && (!errors.Is(err, io.EOF) || len(line) == 0) {
+			if !errors.Is(err, io.EOF) {
+				d.err = err
+			}
+			return false
+		}
+		d.line++
+
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+
+		tx := new(Tx)
+		if err = tx.FromBytes(line); err != nil {
+			decodeErr := &DecodeError{Line: d.line, Err: err}
+			if !d.opts.SkipInvalid {
+				d.err = decodeErr
+				return false
+			}
+			if d.opts.OnInvalid != nil {
+				d.opts.OnInvalid(decodeErr)
+			}
+			continue
+		}
+
+		d.tx = tx
+		return true
+	}
+	return false
+}
+
+// Tx returns the transaction decoded by the last call to Next
+func (d *Decoder) Tx() *Tx {
+	return d.tx
+}
+
+// Err returns the first error that stopped the decoder, if any
+func (d *Decoder) Err() error {
+	return d.err
+}
+
+// Line returns the line number of the last line read
+func (d *Decoder) Line() int {
+	return d.line
+}
+
+// readLine reads a full line regardless of its length, reusing the
+// internal buffer between calls
+func (d *Decoder) readLine() ([]byte, error) {
+	d.buf = d.buf[:0]
+	for {
+		chunk, err := d.r.ReadSlice('\n')
+		d.buf = append(d.buf, chunk...)
+		if !errors.Is(err, bufio.ErrBufferFull) {
+			return d.buf, err
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package bob
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// compactLine returns the BOB json on a single line
+func compactLine(t testing.TB, bobJSON string) string {
+	var buf bytes.Buffer
+	require.NoError(t, json.Compact(&buf, []byte(bobJSON)))
+	return buf.String()
+}
+
+// TestDecoder tests reading an NDJSON stream with the Decoder
+func TestDecoder(t *testing.T) {
+	t.Parallel()
+
+	stream := compactLine(t, sampleBobTx) + "\n\n" + compactLine(t, parityBob) + "\n"
+
+	d := NewDecoder(strings.NewReader(stream))
+
+	var ids []string
+	for d.Next() {
+		ids = append(ids, d.Tx().Tx.Tx.H)
+	}
+	require.NoError(t, d.Err())
+	require.Equal(t, []string{
+		"207eaadc096849e037b8944df21a8bba6d91d8445848db047c0a3f963121e19d",
+		"98a5f6ef18eaea188bdfdc048f89a48af82627a15a76fd53584975f28ab3cc39",
+	}, ids)
+	require.Equal(t, 3, d.Line())
+	require.Nil(t, d.Tx())
+}
+
+// TestDecoder_NoTrailingNewline tests a stream where the last line is not terminated
+func TestDecoder_NoTrailingNewline(t *testing.T) {
+	t.Parallel()
+
+	d := NewDecoder(strings.NewReader(compactLine(t, sampleBobTx)))
+	require.True(t, d.Next())
+	require.Equal(t, "207eaadc096849e037b8944df21a8bba6d91d8445848db047c0a3f963121e19d", d.Tx().Tx.Tx.H)
+	require.False(t, d.Next())
+	require.NoError(t, d.Err())
+}
+
+// TestDecoder_Normalization tests that decoded txs get the same fixes as FromBytes
+func TestDecoder_Normalization(t *testing.T) {
+	t.Parallel()
+
+	d := NewDecoder(strings.NewReader(compactLine(t, sampleBobTx) + "\n"))
+	require.True(t, d.Next())
+
+	bobTx := d.Tx()
+	require.NotNil(t, bobTx.Out[0].E.A)
+	require.Equal(t, "false", *bobTx.Out[0].E.A)
+	require.NotNil(t, bobTx.Out[0].Tape[1].Cell[0].H)
+	require.Equal(t, "e4b880e781afe883bde999a4e58d83e5b9b4e69a97", *bobTx.Out[0].Tape[1].Cell[0].H)
+}
+
+// TestDecoder_LongLine tests lines larger than the default buffer size
+func TestDecoder_LongLine(t *testing.T) {
+	t.Parallel()
+
+	// pad the document with whitespace to exceed any scanner token limit
+	padding := strings.Repeat(" ", 1024*1024)
+	line := "{" + padding + compactLine(t, sampleBobTx)[1:]
+
+	d := NewDecoder(strings.NewReader(line + "\n" + line + "\n"))
+	count := 0
+	for d.Next() {
+		require.Equal(t, "207eaadc096849e037b8944df21a8bba6d91d8445848db047c0a3f963121e19d", d.Tx().Tx.Tx.H)
+		count++
+	}
+	require.NoError(t, d.Err())
+	require.Equal(t, 2, count)
+}
+
+// TestDecoder_Invalid tests that a malformed line stops the decoder
+func TestDecoder_Invalid(t *testing.T) {
+	t.Parallel()
+
+	stream := compactLine(t, sampleBobTx) + "\ninvalid-json\n" + compactLine(t, parityBob) + "\n"
+
+	d := NewDecoder(strings.NewReader(stream))
+	require.True(t, d.Next())
+	require.False(t, d.Next())
+
+	var decodeErr *DecodeError
+	require.ErrorAs(t, d.Err(), &decodeErr)
+	require.Equal(t, 2, decodeErr.Line)
+	require.Contains(t, d.Err().Error(), "line 2:")
+
+	// the decoder stays stopped
+	require.False(t, d.Next())
+}
+
+// TestDecoder_SkipInvalid tests skipping malformed lines
+func TestDecoder_SkipInvalid(t *testing.T) {
+	t.Parallel()
+
+	stream := "invalid-json\n" + compactLine(t, sampleBobTx) + "\n{\"out\": 1}\n" + compactLine(t, parityBob)
+
+	var skipped []int
+	d := NewDecoderWithOptions(strings.NewReader(stream), DecoderOptions{
+		SkipInvalid: true,
+		OnInvalid: func(err *DecodeError) {
+			skipped = append(skipped, err.Line)
+		},
+	})
+
+	count := 0
+	for d.Next() {
+		count++
+	}
+	require.NoError(t, d.Err())
+	require.Equal(t, 2, count)
+	require.Equal(t, []int{1, 3}, skipped)
+}
+
+// ExampleDecoder example using a Decoder
+func ExampleDecoder() {
+	var buf bytes.Buffer
+	_ = json.Compact(&buf, []byte(sampleBobTx))
+
+	d := NewDecoder(&buf)
+	for d.Next() {
+		fmt.Printf("found tx: %s", d.Tx().Tx.Tx.H)
+	}
+	if err := d.Err(); err != nil {
+		fmt.Printf("error occurred: %s", err.Error())
+	}
+	// Output:found tx: 207eaadc096849e037b8944df21a8bba6d91d8445848db047c0a3f963121e19d
+}
+
+// BenchmarkDecoder benchmarks the Decoder
+func BenchmarkDecoder(b *testing.B) {
+	line := compactLine(b, sampleBobTx) + "\n"
+	stream := strings.Repeat(line, 100)
+	for i := 0; i < b.N; i++ {
+		d := NewDecoder(strings.NewReader(stream))
+		for d.Next() {
+			_ = d.Tx()
+		}
+	}
+}
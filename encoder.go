@@ -0,0 +1,122 @@
+package bob
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+
+	"github.com/bitcoinschema/go-bpu"
+)
+
+// ErrLossyProjection is returned when both the B and H representations of
+// cells are omitted, since the cell data could no longer be recovered by NewFromBytes
+var ErrLossyProjection = errors.New("cannot omit both the B and H representations of cells")
+
+// EncoderOptions configures which fields an Encoder writes
+type EncoderOptions struct {
+	OmitB          bool // drop the base64 representation (b, lb) of cells
+	OmitS          bool // drop the string representation (s, ls) of cells
+	OmitH          bool // drop the hex representation (h) of cells
+	OmitInputTapes bool // drop the tapes of all inputs
+	OmitBlk        bool // drop the blk field
+	OmitID         bool // drop the _id field
+}
+
+// Encoder writes newline delimited BOB transactions (NDJSON) to a stream
+//
+// Every line written can be read back with NewFromBytes or a Decoder.
+type Encoder struct {
+	opts EncoderOptions
+	enc  *json.Encoder
+}
+
+// encodedTx mirrors bpu.Tx with the optional fields made omittable
+type encodedTx struct {
+	In   []bpu.Input  `json:"in"`
+	Out  []bpu.Output `json:"out"`
+	ID   *string      `json:"_id,omitempty"`
+	Tx   bpu.TxInfo   `json:"tx"`
+	Blk  *bpu.Blk     `json:"blk,omitempty"`
+	Lock uint32       `json:"lock"`
+}
+
+// NewEncoder creates a new Encoder writing complete BOB transactions to w
+func NewEncoder(w io.Writer) *Encoder {
+	return NewEncoderWithOptions(w, EncoderOptions{})
+}
+
+// NewEncoderWithOptions creates a new Encoder writing to w using the given options
+func NewEncoderWithOptions(w io.Writer, opts EncoderOptions) *Encoder {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return &Encoder{
+		opts: opts,
+		enc:  enc,
+	}
+}
+
+// Encode writes the BOB transaction as a single line to the stream
+func (e *Encoder) Encode(t *Tx) error {
+	if e.opts.OmitB && e.opts.OmitH {
+		return ErrLossyProjection
+	}
+
+	out := encodedTx{
+		In:   t.In,
+		Out:  t.Out,
+		Tx:   t.Tx.Tx,
+		Lock: t.Lock,
+	}
+	if !e.opts.OmitID {
+		out.ID = &t.ID
+	}
+	if !e.opts.OmitBlk {
+		out.Blk = &t.Blk
+	}
+
+	projectCells := e.opts.OmitB || e.opts.OmitS || e.opts.OmitH
+	if projectCells || e.opts.OmitInputTapes {
+		out.In = make([]bpu.Input, len(t.In))
+		for i, in := range t.In {
+			if e.opts.OmitInputTapes {
+				in.Tape = []bpu.Tape{}
+			} else {
+				in.Tape = e.projectTapes(in.Tape)
+			}
+			out.In[i] = in
+		}
+	}
+	if projectCells {
+		out.Out = make([]bpu.Output, len(t.Out))
+		for i, o := range t.Out {
+			o.Tape = e.projectTapes(o.Tape)
+			out.Out[i] = o
+		}
+	}
+
+	return e.enc.Encode(out)
+}
+
+// projectTapes returns a copy of the tapes without the omitted cell fields
+func (e *Encoder) projectTapes(tapes []bpu.Tape) []bpu.Tape {
+	projected := make([]bpu.Tape, len(tapes))
+	for i, tape := range tapes {
+		cells := make([]bpu.Cell, len(tape.Cell))
+		for j, cell := range tape.Cell {
+			if e.opts.OmitB {
+				cell.B = nil
+				cell.LB = nil
+			}
+			if e.opts.OmitS {
+				cell.S = nil
+				cell.LS = nil
+			}
+			if e.opts.OmitH {
+				cell.H = nil
+			}
+			cells[j] = cell
+		}
+		projected[i] = bpu.Tape{Cell: cells, I: tape.I}
+	}
+	return projected
+}
@@ -0,0 +1,125 @@
+package bob
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestEncoder tests writing and reading back an NDJSON stream
+func TestEncoder(t *testing.T) {
+	t.Parallel()
+
+	var (
+		// Testing projections
+		tests = []struct {
+			name       string
+			opts       EncoderOptions
+			absent     []string
+			noInTapes  bool
+			noMetadata bool
+		}{
+			{"full", EncoderOptions{}, nil, false, false},
+			{"omit b", EncoderOptions{OmitB: true}, []string{`"b":`}, false, false},
+			{"omit s", EncoderOptions{OmitS: true}, []string{`"s":`}, false, false},
+			{"omit h", EncoderOptions{OmitH: true}, []string{`"h":"e4b8`}, false, false},
+			{"omit b and s", EncoderOptions{OmitB: true, OmitS: true}, []string{`"b":`, `"s":`}, false, false},
+			{"omit input tapes", EncoderOptions{OmitInputTapes: true}, []string{`"MEUCIQDw`}, true, false},
+			{"omit blk and id", EncoderOptions{OmitBlk: true, OmitID: true}, []string{`"blk":`, `"_id":`}, false, true},
+		}
+	)
+
+	original, err := NewFromString(sampleBobTx)
+	require.NoError(t, err)
+	originalRaw, err := original.ToRawTxString()
+	require.NoError(t, err)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			e := NewEncoderWithOptions(&buf, test.opts)
+			require.NoError(t, e.Encode(original))
+			require.NoError(t, e.Encode(original))
+
+			lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+			require.Len(t, lines, 2)
+			for _, field := range test.absent {
+				require.NotContains(t, lines[0], field)
+			}
+
+			d := NewDecoder(&buf)
+			count := 0
+			for d.Next() {
+				count++
+				decoded := d.Tx()
+				require.Equal(t, original.Tx.Tx.H, decoded.Tx.Tx.H)
+				require.Equal(t, len(original.Out), len(decoded.Out))
+
+				if test.noInTapes {
+					require.Empty(t, decoded.In[0].Tape)
+					continue
+				}
+				if test.noMetadata {
+					require.Empty(t, decoded.ID)
+					require.Zero(t, decoded.Blk.I)
+				} else {
+					require.Equal(t, original.ID, decoded.ID)
+					require.Equal(t, original.Blk, decoded.Blk)
+				}
+
+				raw, rawErr := decoded.ToRawTxString()
+				require.NoError(t, rawErr)
+				require.Equal(t, originalRaw, raw)
+			}
+			require.NoError(t, d.Err())
+			require.Equal(t, 2, count)
+		})
+	}
+
+	// the original tx is left untouched
+	require.NotNil(t, original.Out[0].Tape[1].Cell[0].B)
+	require.NotNil(t, original.Out[0].Tape[1].Cell[0].H)
+}
+
+// TestEncoder_Lossy tests that omitting both B and H is rejected
+func TestEncoder_Lossy(t *testing.T) {
+	t.Parallel()
+
+	bobTx, err := NewFromString(sampleBobTx)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	e := NewEncoderWithOptions(&buf, EncoderOptions{OmitB: true, OmitH: true})
+	require.ErrorIs(t, e.Encode(bobTx), ErrLossyProjection)
+	require.Zero(t, buf.Len())
+}
+
+// ExampleEncoder example using an Encoder
+func ExampleEncoder() {
+	bobTx, err := NewFromString(sampleBobTx)
+	if err != nil {
+		fmt.Printf("error occurred: %s", err.Error())
+		return
+	}
+
+	e := NewEncoderWithOptions(os.Stdout, EncoderOptions{OmitB: true, OmitS: true, OmitInputTapes: true, OmitBlk: true, OmitID: true})
+	if err = e.Encode(bobTx); err != nil {
+		fmt.Printf("error occurred: %s", err.Error())
+	}
+	// Output:{"in":[{"i":0,"tape":[],"e":{"a":"1FFuYLM8a66GddCG25nUbarazeMr5dnUwC","i":1,"h":"3d1fc854830cb7f5cf4e89459f1e2f4331ffed09ad66a02ce1140c553c9d5af1"},"seq":4294967295}],"out":[{"i":0,"tape":[{"cell":[{"i":0,"ii":0,"op":0,"ops":"OP_0"},{"i":1,"ii":1,"op":106,"ops":"OP_RETURN"}],"i":0},{"cell":[{"h":"e4b880e781afe883bde999a4e58d83e5b9b4e69a97","i":0,"ii":2},{"h":"353861353937","i":1,"ii":3}],"i":1}],"e":{"a":"false","v":0,"i":0}},{"i":1,"tape":[{"cell":[{"i":0,"ii":0,"op":118,"ops":"OP_DUP"},{"i":1,"ii":1,"op":169,"ops":"OP_HASH160"},{"h":"9c63715c6d1fa6c61b31d2911516e1c3db3bdfa8","i":2,"ii":2},{"i":3,"ii":3,"op":136,"ops":"OP_EQUALVERIFY"},{"i":4,"ii":4,"op":172,"ops":"OP_CHECKSIG"}],"i":0}],"e":{"a":"1FFuYLM8a66GddCG25nUbarazeMr5dnUwC","v":111411,"i":1}}],"tx":{"h":"207eaadc096849e037b8944df21a8bba6d91d8445848db047c0a3f963121e19d"},"lock":0}
+}
+
+// BenchmarkEncoder benchmarks the Encoder
+func BenchmarkEncoder(b *testing.B) {
+	bobTx, _ := NewFromString(sampleBobTx)
+	var buf bytes.Buffer
+	e := NewEncoderWithOptions(&buf, EncoderOptions{OmitB: true, OmitS: true})
+	for i := 0; i < b.N; i++ {
+		buf.Reset()
+		_ = e.Encode(bobTx)
+	}
+}
@@ -0,0 +1,544 @@
+package bob
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bitcoinschema/go-bpu"
+	"github.com/bsv-blockchain/go-sdk/script"
+)
+
+// LargePushdataSize is the size above which legacy flattened cells use the
+// lb, ls and lh keys instead of b, s and h
+const LargePushdataSize = 512
+
+// Flatten returns the legacy flattened (TXO) representation of the tx, as
+// used by Bitdb and the first Planaria indexers
+//
+// Every input and output has its cells as top level keys, indexed by their
+// position in the whole script rather than in their tape: the pushdata at
+// index N is bN (base64), sN (string) and hN (hex), or lbN, lsN and lhN if
+// it is larger than LargePushdataSize, an opcode at index N is bN = {"op": N}.
+// The index of a cell is its ii, counting the "|" separators left out of the
+// tapes, so the B protocol prefix of an OP_FALSE OP_RETURN output is out.s2.
+//
+//	{"tx": {"h": ...}, "in": [{"i": 0, "b0": ..., "s0": ..., "h0": ..., "e": {...}, "seq": ...}], "out": [{"i": 0, "b0": {"op": 0}, "b1": {"op": 106}, "s2": ..., "e": {...}}]}
+//
+// ii only keeps the index modulo 256, larger scripts are indexed assuming
+// less than 256 cells are left out between two cells. Documents without ii
+// are indexed by counting their cells, assuming a "|" separator before
+// every tape after the first data tape, as ToTx does.
+func (t *Tx) Flatten() (map[string]any, error) {
+	return t.FlattenWithOptions(FlattenOptions{})
+}
+
+// FlattenOptions configures how FlattenWithOptions keys the cells
+type FlattenOptions struct {
+	// TapeRelative keys the cells by their tape and their index in the tape
+	// rather than by their index in the whole script: the cell at index N of
+	// tape T is bT_N, sT_N and hT_N (lbT_N, lsT_N and lhT_N if large), so the
+	// B protocol prefix of an OP_FALSE OP_RETURN output is out.s1_0.
+	TapeRelative bool
+}
+
+// FlattenWithOptions returns the legacy flattened (TXO) representation of
+// the tx using the given options (see Flatten)
+func (t *Tx) FlattenWithOptions(opts FlattenOptions) (map[string]any, error) {
+	flat := map[string]any{
+		"tx":   map[string]any{"h": t.Tx.Tx.H},
+		"lock": t.Lock,
+	}
+	if t.ID != "" {
+		flat["_id"] = t.ID
+	}
+	if t.Blk.I != 0 || t.Blk.T != 0 {
+		flat["blk"] = map[string]any{"i": t.Blk.I, "t": t.Blk.T}
+	}
+
+	ins := make([]any, len(t.In))
+	for i := range t.In {
+		in, err := flattenXPut(&t.In[i].XPut, opts.TapeRelative)
+		if err != nil {
+			return nil, fmt.Errorf("input %d: %w", i, err)
+		}
+		in["seq"] = t.In[i].Seq
+		ins[i] = in
+	}
+	flat["in"] = ins
+
+	outs := make([]any, len(t.Out))
+	for i := range t.Out {
+		out, err := flattenXPut(&t.Out[i].XPut, opts.TapeRelative)
+		if err != nil {
+			return nil, fmt.Errorf("output %d: %w", i, err)
+		}
+		outs[i] = out
+	}
+	flat["out"] = outs
+	return flat, nil
+}
+
+// flattenXPut returns the flattened input or output, its cells keyed by
+// their index in the script or in their tape
+func flattenXPut(xput *bpu.XPut, tapeRelative bool) (map[string]any, error) {
+	flat := map[string]any{"i": xput.I, "e": flattenE(&xput.E)}
+	indexesSet := cellIndexesSet(xput.Tape)
+	index := 0
+	for i := range xput.Tape {
+		for j := range xput.Tape[i].Cell {
+			cell := &xput.Tape[i].Cell[j]
+
+			// skip the cells left out before this one (separators)
+			switch {
+			case indexesSet:
+				index += int(cell.II - uint8(index))
+			case delimiterAssumed(i, j):
+				index++
+			}
+			n := strconv.Itoa(index)
+			if tapeRelative {
+				n = strconv.Itoa(i) + "_" + strconv.Itoa(j)
+			}
+			index++
+
+			if op, ok := cellOpcode(cell); ok {
+				flat["b"+n] = map[string]any{"op": op}
+				continue
+			}
+			data, err := cellBytes(cell)
+			if err != nil && cell.H != nil {
+				// some indexers set an invalid h, fall back to b or s
+				withoutH := *cell
+				withoutH.H = nil
+				data, err = cellBytes(&withoutH)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("cell %s: %w", n, err)
+			}
+			prefix := ""
+			if cell.LB != nil || cell.LS != nil || len(data) > LargePushdataSize {
+				prefix = "l"
+			}
+			flat[prefix+"b"+n] = base64.StdEncoding.EncodeToString(data)
+			flat[prefix+"s"+n] = string(data)
+			flat[prefix+"h"+n] = hex.EncodeToString(data)
+		}
+	}
+	return flat, nil
+}
+
+// flattenE returns the flattened edge of an input or output
+func flattenE(e *bpu.E) map[string]any {
+	flat := map[string]any{"i": e.I}
+	if e.A != nil {
+		flat["a"] = *e.A
+	}
+	if e.V != nil {
+		flat["v"] = *e.V
+	}
+	if e.H != nil {
+		flat["h"] = *e.H
+	}
+	return flat
+}
+
+// Unflatten sets the tx from its legacy flattened (TXO) representation,
+// either returned by Flatten or decoded from JSON
+//
+// The cells are split into tapes using the default split rules (see
+// DefaultSplitConfig): after OP_RETURN, after OP_FALSE and on "|" separators
+// once an OP_RETURN was seen. Cells missing from the flattened form, as "|"
+// separators are from Flatten, also split tapes once an OP_RETURN was seen.
+// Pushdata cells get their b, s and h (lb, ls and h for large pushdatas).
+//
+// Tape-relative keys (see FlattenOptions) keep the cells in their tapes,
+// the tapes after the data tape that do not follow an OP_RETURN or OP_FALSE
+// being assumed to follow a left out "|" separator.
+func (t *Tx) Unflatten(flat map[string]any) error {
+	if flat == nil {
+		return fmt.Errorf("flattened tx must be set")
+	}
+
+	var (
+		bobTx Tx
+		err   error
+	)
+	if id, ok := flat["_id"].(string); ok {
+		bobTx.ID = id
+	}
+	if info, ok := flat["tx"].(map[string]any); ok {
+		bobTx.Tx.Tx.H, _ = info["h"].(string)
+	}
+	if blk, ok := flat["blk"].(map[string]any); ok {
+		if bobTx.Blk.I, err = flatUint32(blk, "i"); err != nil {
+			return fmt.Errorf("blk: %w", err)
+		}
+		if bobTx.Blk.T, err = flatUint32(blk, "t"); err != nil {
+			return fmt.Errorf("blk: %w", err)
+		}
+	}
+	if bobTx.Lock, err = flatUint32(flat, "lock"); err != nil {
+		return err
+	}
+
+	ins, err := flatList(flat, "in")
+	if err != nil {
+		return err
+	}
+	bobTx.In = make([]bpu.Input, len(ins))
+	for i, in := range ins {
+		if bobTx.In[i].XPut, err = unflattenXPut(in); err != nil {
+			return fmt.Errorf("input %d: %w", i, err)
+		}
+		if bobTx.In[i].Seq, err = flatUint32(in, "seq"); err != nil {
+			return fmt.Errorf("input %d: %w", i, err)
+		}
+	}
+
+	outs, err := flatList(flat, "out")
+	if err != nil {
+		return err
+	}
+	bobTx.Out = make([]bpu.Output, len(outs))
+	for i, out := range outs {
+		if bobTx.Out[i].XPut, err = unflattenXPut(out); err != nil {
+			return fmt.Errorf("output %d: %w", i, err)
+		}
+	}
+
+	*t = bobTx
+	return nil
+}
+
+// flatCell is a cell read from a flattened input or output
+type flatCell struct {
+	op    *uint8
+	data  []byte
+	large bool
+}
+
+// unflattenXPut returns the input or output from its flattened form
+func unflattenXPut(flat map[string]any) (bpu.XPut, error) {
+	var (
+		xput bpu.XPut
+		err  error
+	)
+	var i uint32
+	if i, err = flatUint32(flat, "i"); err != nil {
+		return xput, err
+	}
+	xput.I = uint8(i)
+	if e, ok := flat["e"].(map[string]any); ok {
+		if xput.E, err = unflattenE(e); err != nil {
+			return xput, fmt.Errorf("e: %w", err)
+		}
+	}
+
+	var (
+		cells        = make(map[flatKey]*flatCell)
+		tapeRelative *bool
+	)
+	for key, value := range flat {
+		large, kind, index, ok := parseFlatKey(key)
+		if !ok {
+			continue
+		}
+		if isTapeRelative := index.tape >= 0; tapeRelative == nil {
+			tapeRelative = &isTapeRelative
+		} else if *tapeRelative != isTapeRelative {
+			return xput, fmt.Errorf("%s: tape-relative and script keys cannot be mixed", key)
+		}
+		cell := cells[index]
+		if cell == nil {
+			cell = &flatCell{}
+			cells[index] = cell
+		}
+		if err = cell.set(large, kind, value); err != nil {
+			return xput, fmt.Errorf("%s: %w", key, err)
+		}
+	}
+
+	indexes := make([]flatKey, 0, len(cells))
+	for index := range cells {
+		indexes = append(indexes, index)
+	}
+	sort.Slice(indexes, func(i, j int) bool {
+		if indexes[i].tape != indexes[j].tape {
+			return indexes[i].tape < indexes[j].tape
+		}
+		return indexes[i].cell < indexes[j].cell
+	})
+	if tapeRelative != nil && *tapeRelative {
+		xput.Tape, err = unflattenTapes(cells, indexes)
+		return xput, err
+	}
+
+	var (
+		tape     *bpu.Tape
+		opReturn bool
+		next     int
+	)
+	for _, key := range indexes {
+		index, cell := key.cell, cells[key]
+		if cell.op == nil && cell.data == nil {
+			return xput, fmt.Errorf("cell %d has no data", index)
+		}
+
+		// a missing cell is a separator left out of the tapes
+		if index != next && opReturn {
+			tape = nil
+		}
+		next = index + 1
+
+		if opReturn && cell.op == nil && string(cell.data) == ProtocolDelimiter {
+			tape = nil
+			continue
+		}
+
+		if tape == nil {
+			xput.Tape = append(xput.Tape, bpu.Tape{I: uint8(len(xput.Tape))})
+			tape = &xput.Tape[len(xput.Tape)-1]
+		}
+		tape.Cell = append(tape.Cell, cell.bpuCell(uint8(len(tape.Cell)), uint8(index)))
+
+		if cell.op != nil && (*cell.op == script.OpRETURN || (*cell.op == script.OpFALSE && opReturn)) {
+			opReturn = true
+			tape = nil
+		}
+	}
+	return xput, nil
+}
+
+// unflattenTapes returns the tapes of tape-relative cells
+func unflattenTapes(cells map[flatKey]*flatCell, indexes []flatKey) ([]bpu.Tape, error) {
+	var (
+		tapes    []bpu.Tape
+		opReturn bool
+		ii       int
+	)
+	for n, key := range indexes {
+		cell := cells[key]
+		if cell.op == nil && cell.data == nil {
+			return nil, fmt.Errorf("cell %d_%d has no data", key.tape, key.cell)
+		}
+		if key.tape != len(tapes)-1 {
+			if key.tape != len(tapes) || key.cell != 0 {
+				return nil, fmt.Errorf("cell %d_%d is not the next cell", key.tape, key.cell)
+			}
+			if n > 0 {
+				// the default split rules leave the "|" separators out
+				prev := cells[indexes[n-1]]
+				if opReturn && (prev.op == nil || (*prev.op != script.OpRETURN && *prev.op != script.OpFALSE)) {
+					ii++
+				}
+			}
+			tapes = append(tapes, bpu.Tape{I: uint8(len(tapes))})
+		}
+		tape := &tapes[len(tapes)-1]
+		if key.cell != len(tape.Cell) {
+			return nil, fmt.Errorf("cell %d_%d is not the next cell", key.tape, key.cell)
+		}
+		tape.Cell = append(tape.Cell, cell.bpuCell(uint8(key.cell), uint8(ii)))
+		ii++
+
+		if cell.op != nil && *cell.op == script.OpRETURN {
+			opReturn = true
+		}
+	}
+	return tapes, nil
+}
+
+// set sets the b, s or h value of the cell
+func (c *flatCell) set(large bool, kind byte, value any) error {
+	if large {
+		c.large = true
+	}
+	if kind == 'b' {
+		if obj, ok := value.(map[string]any); ok {
+			op, err := flatUint32(obj, "op")
+			if err != nil {
+				return err
+			}
+			if op > 255 {
+				return fmt.Errorf("invalid opcode %d", op)
+			}
+			opcode := uint8(op)
+			c.op = &opcode
+			return nil
+		}
+	}
+	s, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("must be a string")
+	}
+	// h is preferred, then b, s being lossy for binary data
+	switch {
+	case kind == 'h':
+		data, err := hex.DecodeString(s)
+		if err != nil {
+			return fmt.Errorf("invalid hex: %w", err)
+		}
+		c.data = data
+	case kind == 'b':
+		data, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return fmt.Errorf("invalid base64: %w", err)
+		}
+		c.data = data
+	case c.data == nil:
+		c.data = []byte(s)
+	}
+	return nil
+}
+
+// bpuCell returns the cell at the tape-relative index i and script index ii
+func (c *flatCell) bpuCell(i, ii uint8) bpu.Cell {
+	cell := bpu.Cell{I: i, II: ii}
+	if c.op != nil {
+		op := *c.op
+		ops := script.OpCodeValues[op]
+		cell.Op, cell.Ops = &op, &ops
+		return cell
+	}
+	b := base64.StdEncoding.EncodeToString(c.data)
+	s := string(c.data)
+	h := hex.EncodeToString(c.data)
+	cell.H = &h
+	if c.large {
+		cell.LB, cell.LS = &b, &s
+	} else {
+		cell.B, cell.S = &b, &s
+	}
+	return cell
+}
+
+// flatKey is the index of a flattened cell, tape being -1 for script indexes
+type flatKey struct {
+	tape int
+	cell int
+}
+
+// parseFlatKey parses a flattened cell key such as s2, lb3 or the
+// tape-relative s1_0
+func parseFlatKey(key string) (large bool, kind byte, index flatKey, ok bool) {
+	if len(key) > 1 && key[0] == 'l' {
+		large, key = true, key[1:]
+	}
+	if len(key) < 2 || (key[0] != 'b' && key[0] != 's' && key[0] != 'h') {
+		return false, 0, index, false
+	}
+	tape, cell, relative := strings.Cut(key[1:], "_")
+	if !relative {
+		tape, cell = "", tape
+	}
+	index.tape = -1
+	for _, part := range []string{tape, cell} {
+		for _, r := range part {
+			if r < '0' || r > '9' {
+				return false, 0, index, false
+			}
+		}
+	}
+	var err error
+	if index.cell, err = strconv.Atoi(cell); err != nil {
+		return false, 0, index, false
+	}
+	if relative {
+		if index.tape, err = strconv.Atoi(tape); err != nil {
+			return false, 0, index, false
+		}
+	}
+	return large, key[0], index, true
+}
+
+// unflattenE returns the edge of an input or output from its flattened form
+func unflattenE(flat map[string]any) (bpu.E, error) {
+	var (
+		e   bpu.E
+		err error
+	)
+	if e.I, err = flatUint32(flat, "i"); err != nil {
+		return e, err
+	}
+	if a, ok := flat["a"].(string); ok {
+		e.A = &a
+	}
+	if h, ok := flat["h"].(string); ok {
+		e.H = &h
+	}
+	if _, ok := flat["v"]; ok {
+		var v uint64
+		if v, err = flatUint(flat["v"]); err != nil {
+			return e, fmt.Errorf("v: %w", err)
+		}
+		e.V = &v
+	}
+	return e, nil
+}
+
+// flatList returns the objects of the array at the key
+func flatList(flat map[string]any, key string) ([]map[string]any, error) {
+	value, ok := flat[key]
+	if !ok || value == nil {
+		return nil, nil
+	}
+	var list []map[string]any
+	switch t := value.(type) {
+	case []map[string]any:
+		list = t
+	case []any:
+		list = make([]map[string]any, len(t))
+		for i := range t {
+			if list[i], ok = t[i].(map[string]any); !ok {
+				return nil, fmt.Errorf("%s %d must be an object", key, i)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("%s must be an array", key)
+	}
+	return list, nil
+}
+
+// flatUint32 returns the number at the key, 0 if it is not set
+func flatUint32(flat map[string]any, key string) (uint32, error) {
+	value, ok := flat[key]
+	if !ok || value == nil {
+		return 0, nil
+	}
+	n, err := flatUint(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", key, err)
+	}
+	if n > 0xffffffff {
+		return 0, fmt.Errorf("%s: %d is out of range", key, n)
+	}
+	return uint32(n), nil
+}
+
+// flatUint returns the unsigned number, as set by Flatten or decoded from JSON
+func flatUint(value any) (uint64, error) {
+	switch n := value.(type) {
+	case uint8:
+		return uint64(n), nil
+	case uint32:
+		return uint64(n), nil
+	case uint64:
+		return n, nil
+	case int:
+		if n >= 0 {
+			return uint64(n), nil
+		}
+	case float64:
+		if n >= 0 && n == float64(uint64(n)) {
+			return uint64(n), nil
+		}
+	case json.Number:
+		return strconv.ParseUint(n.String(), 10, 64)
+	}
+	return 0, fmt.Errorf("invalid number %v", value)
+}
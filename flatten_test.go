@@ -0,0 +1,341 @@
+package bob
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	test "github.com/bitcoinschema/go-bob/testing"
+	"github.com/bitcoinschema/go-bpu"
+	"github.com/bsv-blockchain/go-sdk/transaction"
+	"github.com/stretchr/testify/require"
+)
+
+// requireSameTapes checks that the xputs have the same tapes, comparing the
+// data of the cells rather than which of b, s and h are set (and not the
+// tape index, which bpu does not always start at 0)
+func requireSameTapes(t *testing.T, expected, actual *bpu.XPut) {
+	require.Len(t, actual.Tape, len(expected.Tape))
+	for i := range expected.Tape {
+		require.Len(t, actual.Tape[i].Cell, len(expected.Tape[i].Cell), "tape %d", i)
+		for j := range expected.Tape[i].Cell {
+			e, a := &expected.Tape[i].Cell[j], &actual.Tape[i].Cell[j]
+			require.Equal(t, e.I, a.I)
+			require.Equal(t, e.II, a.II)
+			expectedOp, isOp := cellOpcode(e)
+			actualOp, _ := cellOpcode(a)
+			require.Equal(t, expectedOp, actualOp)
+			if !isOp {
+				expectedData, err := cellBytes(e)
+				require.NoError(t, err)
+				var actualData []byte
+				actualData, err = cellBytes(a)
+				require.NoError(t, err)
+				require.Equal(t, expectedData, actualData)
+			}
+		}
+	}
+}
+
+// TestTx_Flatten tests the method Flatten()
+func TestTx_Flatten(t *testing.T) {
+	t.Parallel()
+
+	t.Run("legacy cells", func(t *testing.T) {
+		bobTx, err := NewFromRawTxString(rawBobTx)
+		require.NoError(t, err)
+		var flat map[string]any
+		flat, err = bobTx.Flatten()
+		require.NoError(t, err)
+
+		require.Equal(t, map[string]any{"h": bobTx.Tx.Tx.H}, flat["tx"])
+		require.NotContains(t, flat, "blk")
+		out := flat["out"].([]any)[0].(map[string]any)
+		require.Equal(t, uint8(0), out["i"])
+		require.Equal(t, map[string]any{"op": uint8(0)}, out["b0"])
+		require.Equal(t, map[string]any{"op": uint8(106)}, out["b1"])
+		require.Equal(t, "19HxigV4QyBv3tHpQVcUEQyq1pzZVdoAut", out["s2"])
+		require.Equal(t, "MTlIeGlnVjRReUJ2M3RIcFFWY1VFUXlxMXB6WlZkb0F1dA==", out["b2"])
+		require.Equal(t, hex.EncodeToString([]byte("19HxigV4QyBv3tHpQVcUEQyq1pzZVdoAut")), out["h2"])
+
+		// the cells after the "|" separators keep their index in the script
+		for _, tape := range bobTx.Out[0].Tape {
+			for _, cell := range tape.Cell {
+				_, small := out[fmt.Sprintf("b%d", cell.II)]
+				_, large := out[fmt.Sprintf("lb%d", cell.II)]
+				require.True(t, small || large, "cell %d", cell.II)
+			}
+		}
+		require.NotContains(t, out, "b7")
+		require.Contains(t, out, "ls11")
+		in := flat["in"].([]any)[0].(map[string]any)
+		require.Equal(t, bobTx.In[0].Seq, in["seq"])
+		require.Contains(t, in, "h0")
+		require.Contains(t, in, "h1")
+	})
+
+	t.Run("tape relative", func(t *testing.T) {
+		bobTx, err := NewFromRawTxString(rawBobTx)
+		require.NoError(t, err)
+		var flat map[string]any
+		flat, err = bobTx.FlattenWithOptions(FlattenOptions{TapeRelative: true})
+		require.NoError(t, err)
+
+		out := flat["out"].([]any)[0].(map[string]any)
+		require.Equal(t, map[string]any{"op": uint8(0)}, out["b0_0"])
+		require.Equal(t, map[string]any{"op": uint8(106)}, out["b0_1"])
+		require.Equal(t, "19HxigV4QyBv3tHpQVcUEQyq1pzZVdoAut", out["s1_0"])
+		require.NotContains(t, out, "s2")
+
+		// every cell is keyed by its tape and its index in the tape
+		for i, tape := range bobTx.Out[0].Tape {
+			for j := range tape.Cell {
+				_, small := out[fmt.Sprintf("b%d_%d", i, j)]
+				_, large := out[fmt.Sprintf("lb%d_%d", i, j)]
+				require.True(t, small || large, "tape %d cell %d", i, j)
+			}
+		}
+	})
+
+	t.Run("large pushdata", func(t *testing.T) {
+		large := strings.Repeat("a", LargePushdataSize+1)
+		bobTx, err := NewBuilder().AddTape("prefix", []byte(large), []byte("small")).Tx()
+		require.NoError(t, err)
+		var flat map[string]any
+		flat, err = bobTx.Flatten()
+		require.NoError(t, err)
+
+		out := flat["out"].([]any)[0].(map[string]any)
+		require.Equal(t, "prefix", out["s2"])
+		require.Equal(t, large, out["ls3"])
+		require.Contains(t, out, "lb3")
+		require.Contains(t, out, "lh3")
+		require.NotContains(t, out, "s3")
+		require.Equal(t, "small", out["s4"])
+	})
+
+	t.Run("without ii", func(t *testing.T) {
+		bobTx, err := NewFromString(`{"tx":{"h":"00"},"in":[],"out":[{"i":0,"e":{"v":0,"i":0},"tape":[` +
+			`{"i":0,"cell":[{"op":0,"ops":"OP_0","i":0},{"op":106,"ops":"OP_RETURN","i":1}]},` +
+			`{"i":1,"cell":[{"s":"hello","i":0},{"s":"world","i":1}]},` +
+			`{"i":2,"cell":[{"s":"x","i":0}]}]}]}`)
+		require.NoError(t, err)
+		var flat map[string]any
+		flat, err = bobTx.Flatten()
+		require.NoError(t, err)
+
+		// the cells are counted, a "|" being assumed between the data tapes
+		out := flat["out"].([]any)[0].(map[string]any)
+		require.Equal(t, map[string]any{"op": uint8(0)}, out["b0"])
+		require.Equal(t, map[string]any{"op": uint8(106)}, out["b1"])
+		require.Equal(t, "hello", out["s2"])
+		require.Equal(t, "world", out["s3"])
+		require.NotContains(t, out, "s4")
+		require.Equal(t, "x", out["s5"])
+
+		// the same cells ToTx puts in the script
+		var tx *transaction.Transaction
+		tx, err = bobTx.ToTx()
+		require.NoError(t, err)
+		require.Equal(t, "006a0568656c6c6f05776f726c64017c0178", tx.Outputs[0].LockingScript.String())
+
+		var unflattened Tx
+		require.NoError(t, unflattened.Unflatten(flat))
+		require.Len(t, unflattened.Out[0].Tape, 3)
+	})
+
+	t.Run("invalid cell", func(t *testing.T) {
+		h := "invalid"
+		bobTx := &Tx{}
+		bobTx.Out = []bpu.Output{{XPut: bpu.XPut{Tape: []bpu.Tape{{Cell: []bpu.Cell{{H: &h}}}}}}}
+		_, err := bobTx.Flatten()
+		require.Error(t, err)
+
+		// b is used when h is invalid
+		b := "aGVsbG8="
+		bobTx.Out[0].Tape[0].Cell[0].B = &b
+		var flat map[string]any
+		flat, err = bobTx.Flatten()
+		require.NoError(t, err)
+		require.Equal(t, "hello", flat["out"].([]any)[0].(map[string]any)["s0"])
+	})
+}
+
+// TestTx_Unflatten_RoundTrip tests that every fixture survives a round trip
+// through the flattened representation and its JSON
+func TestTx_Unflatten_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	files, err := filepath.Glob("./testing/tx/*.hex")
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+
+	for _, file := range files {
+		for _, opts := range []FlattenOptions{{}, {TapeRelative: true}} {
+			rawTx := test.GetTestHex(file)
+			t.Run(fmt.Sprintf("%s tape relative %t", filepath.Base(file), opts.TapeRelative), func(t *testing.T) {
+				testFlattenRoundTrip(t, rawTx, opts)
+			})
+		}
+	}
+}
+
+// testFlattenRoundTrip checks that the raw tx survives a round trip through
+// the flattened representation and its JSON
+func testFlattenRoundTrip(t *testing.T, rawTx string, opts FlattenOptions) {
+	bobTx, err := NewFromRawTxString(rawTx)
+	require.NoError(t, err)
+	bobTx.Blk = bpu.Blk{I: 635140, T: 1589607858}
+
+	var flat map[string]any
+	flat, err = bobTx.FlattenWithOptions(opts)
+	require.NoError(t, err)
+
+	var data []byte
+	data, err = json.Marshal(flat)
+	require.NoError(t, err)
+	var decoded map[string]any
+	require.NoError(t, json.Unmarshal(data, &decoded))
+
+	for _, f := range []map[string]any{flat, decoded} {
+		unflattened := new(Tx)
+		require.NoError(t, unflattened.Unflatten(f))
+		require.Equal(t, bobTx.Tx.Tx, unflattened.Tx.Tx)
+		require.Equal(t, bobTx.Blk, unflattened.Blk)
+		require.Equal(t, bobTx.Lock, unflattened.Lock)
+		require.Len(t, unflattened.In, len(bobTx.In))
+		for i := range bobTx.In {
+			require.Equal(t, bobTx.In[i].Seq, unflattened.In[i].Seq)
+			require.Equal(t, bobTx.In[i].E, unflattened.In[i].E)
+			requireSameTapes(t, &bobTx.In[i].XPut, &unflattened.In[i].XPut)
+		}
+		require.Len(t, unflattened.Out, len(bobTx.Out))
+		for i := range bobTx.Out {
+			require.Equal(t, bobTx.Out[i].E, unflattened.Out[i].E)
+			requireSameTapes(t, &bobTx.Out[i].XPut, &unflattened.Out[i].XPut)
+		}
+
+		roundTrip, err := unflattened.ToRawTxString()
+		require.NoError(t, err)
+		require.Equal(t, rawTx, roundTrip)
+	}
+}
+
+// TestTx_Unflatten tests the method Unflatten() with legacy documents
+func TestTx_Unflatten(t *testing.T) {
+	t.Parallel()
+
+	t.Run("legacy separators", func(t *testing.T) {
+		// as indexed by Bitdb, with the "|" separators
+		var flat map[string]any
+		require.NoError(t, json.Unmarshal([]byte(`{
+			"tx": {"h": "abc"},
+			"blk": {"i": 600000, "t": 1570000000},
+			"in": [],
+			"out": [{
+				"i": 0,
+				"b0": {"op": 0}, "b1": {"op": 106},
+				"s2": "19HxigV4QyBv3tHpQVcUEQyq1pzZVdoAut", "s3": "hello", "b3": "aGVsbG8=",
+				"s4": "|",
+				"s5": "1PuQa7K62MiKCtssSLKy1kh56WWU7MtUR5", "h6": "534554",
+				"e": {"v": 0, "i": 0, "a": "false"}
+			}]
+		}`), &flat))
+
+		bobTx := new(Tx)
+		require.NoError(t, bobTx.Unflatten(flat))
+		require.Equal(t, "abc", bobTx.Tx.Tx.H)
+		require.Equal(t, uint32(600000), bobTx.Blk.I)
+		require.Len(t, bobTx.Out, 1)
+
+		out := bobTx.Out[0]
+		require.Len(t, out.Tape, 3)
+		require.Len(t, out.Tape[0].Cell, 2)
+		require.Equal(t, "OP_RETURN", *out.Tape[0].Cell[1].Ops)
+		require.Equal(t, uint8(1), out.Tape[1].I)
+		require.Equal(t, "hello", *out.Tape[1].Cell[1].S)
+		require.Equal(t, "68656c6c6f", *out.Tape[1].Cell[1].H)
+		require.Equal(t, uint8(2), out.Tape[2].I)
+		require.Equal(t, uint8(0), out.Tape[2].Cell[0].I)
+		require.Equal(t, uint8(5), out.Tape[2].Cell[0].II)
+		require.Equal(t, "SET", *out.Tape[2].Cell[1].S)
+		require.Equal(t, "false", *out.E.A)
+		require.Equal(t, uint64(0), *out.E.V)
+	})
+
+	t.Run("large pushdata", func(t *testing.T) {
+		bobTx := new(Tx)
+		require.NoError(t, bobTx.Unflatten(map[string]any{
+			"out": []any{map[string]any{"ls0": "large", "lh0": "6c61726765"}},
+		}))
+		cell := bobTx.Out[0].Tape[0].Cell[0]
+		require.Nil(t, cell.S)
+		require.Equal(t, "large", *cell.LS)
+		require.Equal(t, "bGFyZ2U=", *cell.LB)
+	})
+
+	var (
+		// Testing invalid flattened txs
+		tests = []struct {
+			name string
+			flat map[string]any
+		}{
+			{"nil", nil},
+			{"invalid lock", map[string]any{"lock": "0"}},
+			{"negative lock", map[string]any{"lock": float64(-1)}},
+			{"invalid blk", map[string]any{"blk": map[string]any{"i": 1.5}}},
+			{"invalid in", map[string]any{"in": "0"}},
+			{"invalid out", map[string]any{"out": []any{"0"}}},
+			{"invalid seq", map[string]any{"in": []any{map[string]any{"seq": "1"}}}},
+			{"invalid e", map[string]any{"out": []any{map[string]any{"e": map[string]any{"v": "1"}}}}},
+			{"invalid hex", map[string]any{"out": []any{map[string]any{"h0": "zz"}}}},
+			{"invalid base64", map[string]any{"out": []any{map[string]any{"b0": "!"}}}},
+			{"invalid opcode", map[string]any{"out": []any{map[string]any{"b0": map[string]any{"op": float64(256)}}}}},
+			{"invalid string", map[string]any{"out": []any{map[string]any{"s0": float64(1)}}}},
+			{"mixed keys", map[string]any{"out": []any{map[string]any{"s0": "a", "s1_0": "b"}}}},
+			{"missing tape", map[string]any{"out": []any{map[string]any{"s0_0": "a", "s2_0": "b"}}}},
+			{"missing cell", map[string]any{"out": []any{map[string]any{"s0_0": "a", "s0_2": "b"}}}},
+		}
+	)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Error(t, new(Tx).Unflatten(test.flat))
+		})
+	}
+}
+
+// ExampleTx_Flatten example using Flatten()
+func ExampleTx_Flatten() {
+	bobTx, err := NewFromRawTxString(rawBobTx)
+	if err != nil {
+		fmt.Printf("error occurred: %s", err.Error())
+		return
+	}
+	var flat map[string]any
+	if flat, err = bobTx.Flatten(); err != nil {
+		fmt.Printf("error occurred: %s", err.Error())
+		return
+	}
+	fmt.Printf("out.s2: %s", flat["out"].([]any)[0].(map[string]any)["s2"])
+	// Output:out.s2: 19HxigV4QyBv3tHpQVcUEQyq1pzZVdoAut
+}
+
+// BenchmarkTx_Flatten benchmarks the method Flatten()
+func BenchmarkTx_Flatten(b *testing.B) {
+	bobTx, _ := NewFromRawTxString(rawBobTx)
+	for i := 0; i < b.N; i++ {
+		_, _ = bobTx.Flatten()
+	}
+}
+
+// BenchmarkTx_Unflatten benchmarks the method Unflatten()
+func BenchmarkTx_Unflatten(b *testing.B) {
+	bobTx, _ := NewFromRawTxString(rawBobTx)
+	flat, _ := bobTx.Flatten()
+	for i := 0; i < b.N; i++ {
+		_ = new(Tx).Unflatten(flat)
+	}
+}
@@ -0,0 +1,259 @@
+package path
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// step is a field of the path and the selectors applied to its elements
+type step struct {
+	name      string
+	def       *field
+	selectors []selector
+}
+
+// selector selects elements of a list field: all of them (index and filter
+// both nil), the one at an index or the ones matching a filter
+type selector struct {
+	index  *int
+	filter orFilter
+}
+
+// orFilter matches elements matching any of its and filters
+type orFilter []andFilter
+
+// andFilter matches elements matching all of its comparisons
+type andFilter []*comparison
+
+// comparison compares the values of a relative path to a literal, or checks
+// that the path has values if op is empty
+type comparison struct {
+	steps   []*step
+	op      string
+	literal any
+}
+
+// parser parses a path expression
+type parser struct {
+	src string
+	pos int
+}
+
+// parseSteps parses the fields of a path starting from the kind, which
+// must end with a value field
+func (p *parser) parseSteps(k kind) ([]*step, error) {
+	var steps []*step
+	for {
+		s, err := p.parseStep(k)
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, s)
+		k = s.def.kind
+
+		p.skipSpace()
+		if !p.consume(".") {
+			break
+		}
+	}
+	if k != kindValue {
+		return nil, p.errorf("%q must be followed by one of %s", steps[len(steps)-1].name, strings.Join(fieldNames(k), ", "))
+	}
+	return steps, nil
+}
+
+// parseStep parses a field and its selectors
+func (p *parser) parseStep(k kind) (*step, error) {
+	p.skipSpace()
+	name := p.ident()
+	if name == "" {
+		return nil, p.errorf("expected a field")
+	}
+	def, ok := fields[k][name]
+	if !ok {
+		return nil, p.errorf("unknown field %q, expected one of %s", name, strings.Join(fieldNames(k), ", "))
+	}
+
+	s := &step{name: name, def: def}
+	for {
+		p.skipSpace()
+		if !p.consume("[") {
+			return s, nil
+		}
+		if !def.list {
+			return nil, p.errorf("field %q is not a list", name)
+		}
+		sel, err := p.parseSelector(def.kind)
+		if err != nil {
+			return nil, err
+		}
+		s.selectors = append(s.selectors, sel)
+	}
+}
+
+// parseSelector parses a selector, after its opening bracket
+func (p *parser) parseSelector(k kind) (selector, error) {
+	var sel selector
+	p.skipSpace()
+	switch {
+	case p.consume("*"):
+	case p.consume("?"):
+		filter, err := p.parseFilter(k)
+		if err != nil {
+			return sel, err
+		}
+		sel.filter = filter
+	default:
+		start := p.pos
+		p.consume("-")
+		for p.pos < len(p.src) && isDigit(p.src[p.pos]) {
+			p.pos++
+		}
+		index, err := strconv.Atoi(p.src[start:p.pos])
+		if err != nil {
+			p.pos = start
+			return sel, p.errorf("expected *, an index or a ?filter")
+		}
+		sel.index = &index
+	}
+	p.skipSpace()
+	if !p.consume("]") {
+		return sel, p.errorf("expected ]")
+	}
+	return sel, nil
+}
+
+// parseFilter parses the comparisons of a filter, joined by && and ||
+func (p *parser) parseFilter(k kind) (orFilter, error) {
+	var (
+		filter orFilter
+		and    andFilter
+	)
+	for {
+		c, err := p.parseComparison(k)
+		if err != nil {
+			return nil, err
+		}
+		and = append(and, c)
+
+		p.skipSpace()
+		switch {
+		case p.consume("&&"):
+		case p.consume("||"):
+			filter = append(filter, and)
+			and = nil
+		default:
+			return append(filter, and), nil
+		}
+	}
+}
+
+// parseComparison parses a relative path, optionally compared to a literal
+func (p *parser) parseComparison(k kind) (*comparison, error) {
+	var (
+		c   = &comparison{}
+		err error
+	)
+	if c.steps, err = p.parseSteps(k); err != nil {
+		return nil, err
+	}
+
+	p.skipSpace()
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if p.consume(op) {
+			c.op = op
+			break
+		}
+	}
+	if c.op == "" {
+		return c, nil
+	}
+	if c.literal, err = p.parseLiteral(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// parseLiteral parses a quoted string or a number
+func (p *parser) parseLiteral() (any, error) {
+	p.skipSpace()
+	if p.pos >= len(p.src) {
+		return nil, p.errorf("expected a string or a number")
+	}
+
+	if quote := p.src[p.pos]; quote == '"' || quote == '\'' {
+		end := p.pos + 1
+		for end < len(p.src) && p.src[end] != quote {
+			if p.src[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(p.src) {
+			return nil, p.errorf("unterminated string")
+		}
+		literal := p.src[p.pos : end+1]
+		if quote == '\'' {
+			inner := strings.ReplaceAll(literal[1:len(literal)-1], `\'`, `'`)
+			literal = `"` + strings.ReplaceAll(inner, `"`, `\"`) + `"`
+		}
+		s, err := strconv.Unquote(literal)
+		if err != nil {
+			return nil, p.errorf("invalid string %s", p.src[p.pos:end+1])
+		}
+		p.pos = end + 1
+		return s, nil
+	}
+
+	start := p.pos
+	p.consume("-")
+	for p.pos < len(p.src) && (isDigit(p.src[p.pos]) || p.src[p.pos] == '.') {
+		p.pos++
+	}
+	n, err := strconv.ParseFloat(p.src[start:p.pos], 64)
+	if err != nil {
+		p.pos = start
+		return nil, p.errorf("expected a string or a number")
+	}
+	return n, nil
+}
+
+// ident reads an identifier
+func (p *parser) ident() string {
+	start := p.pos
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		if c != '_' && !isDigit(c) && (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') {
+			break
+		}
+		p.pos++
+	}
+	return p.src[start:p.pos]
+}
+
+// consume reads the token if it is next
+func (p *parser) consume(token string) bool {
+	if strings.HasPrefix(p.src[p.pos:], token) {
+		p.pos += len(token)
+		return true
+	}
+	return false
+}
+
+// skipSpace skips spaces and tabs
+func (p *parser) skipSpace() {
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// errorf returns an error at the current position
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("invalid path %q at %d: %s", p.src, p.pos, fmt.Sprintf(format, args...))
+}
+
+// isDigit returns true if c is a decimal digit
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,394 @@
+// Package path evaluates path expressions selecting values of BOB transactions
+//
+// A path is a list of fields separated by dots, starting from the tx and
+// ending with a value. List fields (in, out, tape and cell) select all of
+// their elements, or the ones picked by selectors: [*] for all of them, [N]
+// for the element at index N ([-1] being the last one) and [?filter] for the
+// ones matching the filter:
+//
+//	out[*].tape[?prefix=="1PuQa7K62MiKCtssSLKy1kh56WWU7MtUR5"].cell[2].s
+//
+// A filter compares fields of the element to a "string" (or 'string') or a
+// number with ==, !=, <, <=, > or >=, or checks that a field has a value if
+// there is no comparison. Comparisons are joined with && and || (&& first):
+//
+//	out.tape[?cell[0].s=="19HxigV4QyBv3tHpQVcUEQyq1pzZVdoAut" && cell[2].s=="text/plain"].cell[1].b
+//
+// The data of a cell is read as a string with s, hex with h and base64 with b.
+//
+// Fields:
+//   - tx: in, out, tx.h, blk.i, blk.t, lock and _id
+//   - in and out: i, tape, e.a, e.v, e.i, e.h and seq (inputs only)
+//   - tape: i, prefix (the string of its first cell) and cell
+//   - cell: i, ii, s, h, b, op and ops (opcodes only)
+package path
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"sort"
+
+	"github.com/bitcoinschema/go-bob"
+	"github.com/bitcoinschema/go-bpu"
+)
+
+// kind is the kind of the elements of a field
+type kind int
+
+// Kinds of elements
+const (
+	kindTx kind = iota
+	kindInfo
+	kindBlk
+	kindXPut
+	kindE
+	kindTape
+	kindCell
+	kindValue
+)
+
+// field is a field of an element
+type field struct {
+	kind kind
+	list bool
+	get  func(element any) ([]any, error)
+}
+
+// fields are the fields of each kind of element
+var fields = map[kind]map[string]*field{
+	kindTx: {
+		"in": {kind: kindXPut, list: true, get: func(e any) ([]any, error) {
+			tx := e.(*bob.Tx)
+			ins := make([]any, len(tx.In))
+			for i := range tx.In {
+				ins[i] = &tx.In[i]
+			}
+			return ins, nil
+		}},
+		"out": {kind: kindXPut, list: true, get: func(e any) ([]any, error) {
+			tx := e.(*bob.Tx)
+			outs := make([]any, len(tx.Out))
+			for i := range tx.Out {
+				outs[i] = &tx.Out[i]
+			}
+			return outs, nil
+		}},
+		"tx":   {kind: kindInfo, get: func(e any) ([]any, error) { return []any{&e.(*bob.Tx).Tx.Tx}, nil }},
+		"blk":  {kind: kindBlk, get: func(e any) ([]any, error) { return []any{&e.(*bob.Tx).Blk}, nil }},
+		"lock": {kind: kindValue, get: func(e any) ([]any, error) { return values(uint64(e.(*bob.Tx).Lock)), nil }},
+		"_id": {kind: kindValue, get: func(e any) ([]any, error) {
+			if id := e.(*bob.Tx).ID; id != "" {
+				return values(id), nil
+			}
+			return nil, nil
+		}},
+	},
+	kindInfo: {
+		"h": {kind: kindValue, get: func(e any) ([]any, error) { return values(e.(*bpu.TxInfo).H), nil }},
+	},
+	kindBlk: {
+		"i": {kind: kindValue, get: func(e any) ([]any, error) { return values(uint64(e.(*bpu.Blk).I)), nil }},
+		"t": {kind: kindValue, get: func(e any) ([]any, error) { return values(uint64(e.(*bpu.Blk).T)), nil }},
+	},
+	kindXPut: {
+		"i": {kind: kindValue, get: func(e any) ([]any, error) { return values(uint64(xput(e).I)), nil }},
+		"e": {kind: kindE, get: func(e any) ([]any, error) { return []any{&xput(e).E}, nil }},
+		"tape": {kind: kindTape, list: true, get: func(e any) ([]any, error) {
+			x := xput(e)
+			tapes := make([]any, len(x.Tape))
+			for i := range x.Tape {
+				tapes[i] = &x.Tape[i]
+			}
+			return tapes, nil
+		}},
+		"seq": {kind: kindValue, get: func(e any) ([]any, error) {
+			if in, ok := e.(*bpu.Input); ok {
+				return values(uint64(in.Seq)), nil
+			}
+			return nil, nil
+		}},
+	},
+	kindE: {
+		"a": {kind: kindValue, get: func(e any) ([]any, error) { return optional(e.(*bpu.E).A), nil }},
+		"v": {kind: kindValue, get: func(e any) ([]any, error) { return optional(e.(*bpu.E).V), nil }},
+		"i": {kind: kindValue, get: func(e any) ([]any, error) { return values(uint64(e.(*bpu.E).I)), nil }},
+		"h": {kind: kindValue, get: func(e any) ([]any, error) { return optional(e.(*bpu.E).H), nil }},
+	},
+	kindTape: {
+		"i": {kind: kindValue, get: func(e any) ([]any, error) { return values(uint64(e.(*bpu.Tape).I)), nil }},
+		"prefix": {kind: kindValue, get: func(e any) ([]any, error) {
+			prefix, err := (*bob.Tape)(e.(*bpu.Tape)).Prefix()
+			if err != nil {
+				// no prefix, such as an opcode
+				return nil, nil //nolint:nilerr // the tape has no prefix value
+			}
+			return values(prefix), nil
+		}},
+		"cell": {kind: kindCell, list: true, get: func(e any) ([]any, error) {
+			tape := e.(*bpu.Tape)
+			cells := make([]any, len(tape.Cell))
+			for i := range tape.Cell {
+				cells[i] = &tape.Cell[i]
+			}
+			return cells, nil
+		}},
+	},
+	kindCell: {
+		"i":  {kind: kindValue, get: func(e any) ([]any, error) { return values(uint64(e.(*bpu.Cell).I)), nil }},
+		"ii": {kind: kindValue, get: func(e any) ([]any, error) { return values(uint64(e.(*bpu.Cell).II)), nil }},
+		"s":  {kind: kindValue, get: cellData(func(data []byte) string { return string(data) })},
+		"h":  {kind: kindValue, get: cellData(hex.EncodeToString)},
+		"b":  {kind: kindValue, get: cellData(base64.StdEncoding.EncodeToString)},
+		"op": {kind: kindValue, get: func(e any) ([]any, error) {
+			if op, ok := (*bob.Cell)(e.(*bpu.Cell)).Opcode(); ok {
+				return values(uint64(op)), nil
+			}
+			return nil, nil
+		}},
+		"ops": {kind: kindValue, get: func(e any) ([]any, error) {
+			cell := e.(*bpu.Cell)
+			if _, ok := (*bob.Cell)(cell).Opcode(); ok && cell.Ops != nil {
+				return values(*cell.Ops), nil
+			}
+			return nil, nil
+		}},
+	},
+}
+
+// Path is a compiled path expression, safe for concurrent use
+type Path struct {
+	expr  string
+	steps []*step
+}
+
+// Compile compiles the path expression
+func Compile(expr string) (*Path, error) {
+	p := &parser{src: expr}
+	steps, err := p.parseSteps(kindTx)
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos != len(expr) {
+		return nil, p.errorf("unexpected %q", expr[p.pos:])
+	}
+	return &Path{expr: expr, steps: steps}, nil
+}
+
+// MustCompile compiles the path expression, panicking if it is invalid
+func MustCompile(expr string) *Path {
+	p, err := Compile(expr)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// String returns the path expression
+func (p *Path) String() string {
+	return p.expr
+}
+
+// Eval returns the values selected by the path in the tx
+//
+// Values are strings, except the numbers (i, ii, op, e.v, seq, lock, blk.i
+// and blk.t) which are uint64.
+func (p *Path) Eval(t *bob.Tx) ([]any, error) {
+	if t == nil {
+		return nil, nil
+	}
+	return evalSteps(t, p.steps)
+}
+
+// Eval compiles the path expression and returns the values it selects in the tx
+func Eval(expr string, t *bob.Tx) ([]any, error) {
+	p, err := Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	return p.Eval(t)
+}
+
+// evalSteps returns the values selected by the steps from the element
+func evalSteps(element any, steps []*step) ([]any, error) {
+	elements := []any{element}
+	for _, s := range steps {
+		var next []any
+		for _, e := range elements {
+			children, err := s.def.get(e)
+			if err != nil {
+				return nil, err
+			}
+			for _, sel := range s.selectors {
+				if children, err = sel.apply(children); err != nil {
+					return nil, err
+				}
+			}
+			next = append(next, children...)
+		}
+		elements = next
+	}
+	return elements, nil
+}
+
+// apply returns the elements selected
+func (sel *selector) apply(elements []any) ([]any, error) {
+	switch {
+	case sel.index != nil:
+		i := *sel.index
+		if i < 0 {
+			i += len(elements)
+		}
+		if i < 0 || i >= len(elements) {
+			return nil, nil
+		}
+		return elements[i : i+1], nil
+	case sel.filter != nil:
+		var selected []any
+		for _, e := range elements {
+			ok, err := sel.filter.match(e)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				selected = append(selected, e)
+			}
+		}
+		return selected, nil
+	}
+	return elements, nil
+}
+
+// match returns true if the element matches any of the and filters
+func (f orFilter) match(element any) (bool, error) {
+	for _, and := range f {
+		ok, err := and.match(element)
+		if err != nil || ok {
+			return ok, err
+		}
+	}
+	return false, nil
+}
+
+// match returns true if the element matches all of the comparisons
+func (f andFilter) match(element any) (bool, error) {
+	for _, c := range f {
+		ok, err := c.match(element)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// match returns true if any value of the element at the path compares to
+// the literal, or for !=, if none of them is equal to it
+func (c *comparison) match(element any) (bool, error) {
+	vals, err := evalSteps(element, c.steps)
+	if err != nil {
+		return false, err
+	}
+	switch c.op {
+	case "":
+		return len(vals) > 0, nil
+	case "!=":
+		for _, v := range vals {
+			if cmp, ok := compare(v, c.literal); ok && cmp == 0 {
+				return false, nil
+			}
+		}
+		return true, nil
+	}
+	for _, v := range vals {
+		cmp, ok := compare(v, c.literal)
+		if !ok {
+			continue
+		}
+		switch {
+		case c.op == "==" && cmp == 0,
+			c.op == "<" && cmp < 0,
+			c.op == "<=" && cmp <= 0,
+			c.op == ">" && cmp > 0,
+			c.op == ">=" && cmp >= 0:
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// compare compares a value to a literal of the same type (string or number)
+func compare(v, literal any) (int, bool) {
+	switch l := literal.(type) {
+	case string:
+		s, ok := v.(string)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case s < l:
+			return -1, true
+		case s > l:
+			return 1, true
+		}
+		return 0, true
+	case float64:
+		n, ok := v.(uint64)
+		if !ok {
+			return 0, false
+		}
+		switch f := float64(n); {
+		case f < l:
+			return -1, true
+		case f > l:
+			return 1, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// xput returns the input or output
+func xput(e any) *bpu.XPut {
+	if in, ok := e.(*bpu.Input); ok {
+		return &in.XPut
+	}
+	return &e.(*bpu.Output).XPut
+}
+
+// values returns the value as a list
+func values(v any) []any {
+	return []any{v}
+}
+
+// optional returns the value as a list, empty if it is not set
+func optional[T any](v *T) []any {
+	if v == nil {
+		return nil
+	}
+	return []any{*v}
+}
+
+// cellData returns a field encoding the data of pushdata cells
+func cellData(encode func([]byte) string) func(any) ([]any, error) {
+	return func(e any) ([]any, error) {
+		cell := (*bob.Cell)(e.(*bpu.Cell))
+		if _, ok := cell.Opcode(); ok {
+			return nil, nil
+		}
+		data, err := cell.Bytes()
+		if err != nil {
+			return nil, err
+		}
+		return values(encode(data)), nil
+	}
+}
+
+// fieldNames returns the sorted names of the fields of the kind
+func fieldNames(k kind) []string {
+	names := make([]string, 0, len(fields[k]))
+	for name := range fields[k] {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
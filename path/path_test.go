@@ -0,0 +1,171 @@
+package path
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/bitcoinschema/go-bob"
+	test "github.com/bitcoinschema/go-bob/testing"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	bPrefix   = "19HxigV4QyBv3tHpQVcUEQyq1pzZVdoAut"
+	mapPrefix = "1PuQa7K62MiKCtssSLKy1kh56WWU7MtUR5"
+	aipPrefix = "15PciHG22SNLQJXMoSUaWVi7WSqc7hCfva"
+)
+
+// testTx returns the Twetch test tx (B, MAP and AIP tapes)
+func testTx(t testing.TB) *bob.Tx {
+	tx, err := bob.NewFromRawTxString(test.GetTestHex("../testing/tx/2.hex"))
+	require.NoError(t, err)
+	return tx
+}
+
+// TestPath_Eval tests the method Eval()
+func TestPath_Eval(t *testing.T) {
+	t.Parallel()
+
+	var (
+		// Testing fields, selectors and filters against the test tx
+		tests = []struct {
+			expr     string
+			expected []any
+		}{
+			{"tx.h", []any{"9ec47d91ff11edb62f337dc828c52e39072d1a5a2f1b180bbfae9c3279d81a7c"}},
+			{"lock", []any{uint64(0)}},
+			{"_id", nil},
+			{"in.e.h", []any{"0eebab7ee8d7dfeb11fab254d60c8dc51edb16003b0f86a52a45114a88a0818f"}},
+			{"in[0].seq", []any{uint64(4294967295)}},
+			{"out.seq", nil},
+			{"out.e.v", []any{uint64(0), uint64(4331), uint64(603620)}},
+			{"out[-1].e.a", []any{"15HqYP2qHH8TuV1zwzVyw8tBRfVSJ6x8vL"}},
+			{"out[5].e.a", nil},
+			{"out[*].tape[?prefix==\"" + mapPrefix + "\"].cell[2].s", []any{"twdata_json"}},
+			{"out.tape[?prefix=='" + bPrefix + "'].cell[2].s", []any{"text/plain"}},
+			{"out.tape[1].cell[0].h", []any{"31394878696756345179427633744870515663554551797131707a5a56646f417574"}},
+			{"out.tape[1].cell[0].b", []any{"MTlIeGlnVjRReUJ2M3RIcFFWY1VFUXlxMXB6WlZkb0F1dA=="}},
+			{"out.tape[1].cell[-1].s", []any{"twetch.txt"}},
+			{"out.tape[1].cell[?i>=3].s", []any{"text", "twetch.txt"}},
+			{"out.tape[1].cell[?ii==3 || ii==5].s", []any{" ", "text"}},
+			{"out.tape[?cell[0].s==\"" + bPrefix + "\" && cell[2].s==\"text/plain\"].cell[4].s", []any{"twetch.txt"}},
+			{"out.tape[?cell[0].s==\"" + bPrefix + "\" && cell[2].s==\"image/png\"].cell[4].s", nil},
+			{"out.tape[?prefix!=\"" + bPrefix + "\"].prefix", []any{mapPrefix, aipPrefix}},
+			{"out[0].tape[0].cell.op", []any{uint64(0), uint64(106)}},
+			{"out[0].tape[0].cell.ops", []any{"OP_FALSE", "OP_RETURN"}},
+			{"out[0].tape[0].cell.s", nil},
+			{"out.tape[?cell.op==106].cell[?op].i", []any{uint64(0), uint64(1)}},
+			{"out.tape[?prefix].cell[0].ii", []any{uint64(2), uint64(8), uint64(29)}},
+			{"out[0].tape[?cell.s==\"SET\"].cell[?s<\"2\"].s", []any{mapPrefix}},
+			{"out[ * ] . tape[ 3 ] . cell[ 1 ] . s", []any{"BITCOIN_ECDSA"}},
+			{"out[?e.v>4000 && e.v<5000].e.i", []any{uint64(1)}},
+			{"out[?e.v>=4331.0 && e.v<=4331].e.i", []any{uint64(1)}},
+			{"out[?e.v>-1 && e.a!='it\\'s'].e.i", []any{uint64(0), uint64(1), uint64(2)}},
+			{"out.tape[?cell.s==\"\\u0020\"].prefix", []any{bPrefix}},
+			{"out.tape[?prefix==1].i", nil},
+		}
+	)
+
+	tx := testTx(t)
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			p, err := Compile(test.expr)
+			require.NoError(t, err)
+			require.Equal(t, test.expr, p.String())
+
+			var values []any
+			values, err = p.Eval(tx)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, values)
+		})
+	}
+}
+
+// TestCompile_Errors tests the expressions Compile() rejects
+func TestCompile_Errors(t *testing.T) {
+	t.Parallel()
+
+	var (
+		// Testing invalid expressions
+		tests = []string{
+			"",
+			"out",
+			"out.tape.cell",
+			"outs.tape",
+			"out.tape.cell.x",
+			"tx[0].h",
+			"out[",
+			"out[0",
+			"out[a].i",
+			"out[0:1].i",
+			"out[?].i",
+			"out[?tape].i",
+			"out[?i==].i",
+			"out[?i==x].i",
+			"out[?i==\"1].i",
+			"out[?i==1 &&].i",
+			"out.i extra",
+			"out..i",
+		}
+	)
+
+	for _, expr := range tests {
+		t.Run(expr, func(t *testing.T) {
+			_, err := Compile(expr)
+			require.Error(t, err)
+			require.Panics(t, func() { MustCompile(expr) })
+		})
+	}
+}
+
+// TestEval tests the method Eval()
+func TestEval(t *testing.T) {
+	t.Parallel()
+
+	tx := testTx(t)
+	values, err := Eval("out.tape[?prefix==\""+aipPrefix+"\"].cell[2].s", tx)
+	require.NoError(t, err)
+	require.Equal(t, []any{"148WDH6nFWv5gH81wepCrk5fHkJwEPAQ4Q"}, values)
+
+	_, err = Eval("out.tape", tx)
+	require.Error(t, err)
+
+	values, err = MustCompile("tx.h").Eval(nil)
+	require.NoError(t, err)
+	require.Empty(t, values)
+
+	// cells with invalid data
+	invalid := "invalid"
+	tx.Out[0].Tape[1].Cell[0].H = &invalid
+	_, err = Eval("out.tape.cell.s", tx)
+	require.Error(t, err)
+	_, err = Eval("out.tape[?cell.s==\"x\"].i", tx)
+	require.Error(t, err)
+}
+
+// ExampleCompile example using Compile()
+func ExampleCompile() {
+	tx, err := bob.NewFromRawTxString(test.GetTestHex("../testing/tx/2.hex"))
+	if err != nil {
+		fmt.Printf("error occurred: %s", err.Error())
+		return
+	}
+
+	p := MustCompile(`out[*].tape[?prefix=="1PuQa7K62MiKCtssSLKy1kh56WWU7MtUR5"].cell[2].s`)
+	var values []any
+	if values, err = p.Eval(tx); err != nil {
+		fmt.Printf("error occurred: %s", err.Error())
+		return
+	}
+	fmt.Printf("MAP key: %s", values[0])
+	// Output:MAP key: twdata_json
+}
+
+// BenchmarkPath_Eval benchmarks the method Eval()
+func BenchmarkPath_Eval(b *testing.B) {
+	tx := testTx(b)
+	p := MustCompile(`out[*].tape[?prefix=="1PuQa7K62MiKCtssSLKy1kh56WWU7MtUR5"].cell[2].s`)
+	for i := 0; i < b.N; i++ {
+		_, _ = p.Eval(tx)
+	}
+}
@@ -0,0 +1,279 @@
+// Package aip decodes and verifies AIP (Author Identity Protocol) tapes
+//
+// Specs: https://github.com/BitcoinFiles/AUTHOR_IDENTITY_PROTOCOL
+//
+// An AIP tape is made of the prefix, the signing algorithm, the signing
+// address, the signature and an optional list of field indexes:
+//
+//	15PciHG22SNLQJXMoSUaWVi7WSqc7hCfva BITCOIN_ECDSA <address> <signature> [<index>...]
+//
+// The signature covers the fields of the output before the AIP tape:
+// OP_RETURN (as 0x6a), every pushdata and a "|" for every protocol
+// delimiter, including the one right before the AIP tape. Field indexes
+// select which of those fields were signed, OP_RETURN being index 0.
+package aip
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+
+	"github.com/bitcoinschema/go-bob"
+	bsm "github.com/bsv-blockchain/go-sdk/compat/bsm"
+	"github.com/bsv-blockchain/go-sdk/script"
+)
+
+// Prefix is the Bitcom address of the AIP protocol
+const Prefix = "15PciHG22SNLQJXMoSUaWVi7WSqc7hCfva"
+
+// AlgorithmBitcoinECDSA signs with a Bitcoin Signed Message compact signature
+const AlgorithmBitcoinECDSA = "BITCOIN_ECDSA"
+
+// compactSignatureLength is the length of a compact signature in bytes
+const compactSignatureLength = 65
+
+// AIP is a decoded AIP tape
+//
+// Valid and RecoveredAddress are set by Verify.
+type AIP struct {
+	Algorithm        string `json:"algorithm"`
+	Address          string `json:"address"`
+	Signature        []byte `json:"signature"`
+	Indexes          []int  `json:"indexes,omitempty"`
+	Valid            bool   `json:"valid"`
+	RecoveredAddress string `json:"recovered_address,omitempty"`
+}
+
+// NewFromTape decodes an AIP tape without verifying it
+//
+// The signature may be base64 encoded or the raw compact signature,
+// field indexes may be decimal strings or script numbers.
+func NewFromTape(tape *bob.Tape) (*AIP, error) {
+	if tape == nil {
+		return nil, fmt.Errorf("tape must be set")
+	}
+	if len(tape.Cell) < 4 {
+		return nil, fmt.Errorf("AIP tape must have at least 4 cells, got %d", len(tape.Cell))
+	}
+
+	prefix, err := tape.Prefix()
+	if err != nil {
+		return nil, err
+	}
+	if prefix != Prefix {
+		return nil, fmt.Errorf("tape prefix %q is not %s", prefix, Prefix)
+	}
+
+	var fields [3][]byte
+	for i := range fields {
+		var c *bob.Cell
+		if c, err = tape.CellAt(i + 1); err != nil {
+			return nil, err
+		}
+		if fields[i], err = c.Bytes(); err != nil {
+			return nil, fmt.Errorf("cell %d: %w", i+1, err)
+		}
+	}
+
+	a := &AIP{Algorithm: string(fields[0]), Address: string(fields[1])}
+	if a.Signature, err = decodeSignature(fields[2]); err != nil {
+		return nil, err
+	}
+	for i := 4; i < len(tape.Cell); i++ {
+		var index int
+		if index, err = decodeIndex((*bob.Cell)(&tape.Cell[i])); err != nil {
+			return nil, fmt.Errorf("cell %d: %w", i, err)
+		}
+		a.Indexes = append(a.Indexes, index)
+	}
+	return a, nil
+}
+
+// decodeSignature returns the compact signature of a signature cell
+func decodeSignature(data []byte) ([]byte, error) {
+	if sig, err := base64.StdEncoding.DecodeString(string(data)); err == nil && len(sig) == compactSignatureLength {
+		return sig, nil
+	}
+	if len(data) == compactSignatureLength {
+		return data, nil
+	}
+	return nil, fmt.Errorf("signature is not a base64 or raw compact signature")
+}
+
+// decodeIndex returns the field index of an index cell
+func decodeIndex(c *bob.Cell) (int, error) {
+	s, err := c.String()
+	if err == nil {
+		var index int
+		if index, err = strconv.Atoi(s); err == nil && index >= 0 {
+			return index, nil
+		}
+	}
+	n, err := c.Int()
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("invalid field index %d", n)
+	}
+	return int(n), nil
+}
+
+// Fields returns the fields an AIP tape following the tapes would sign
+//
+// The tapes are those of one output up to the AIP tape. Fields start at
+// the OP_RETURN, a "|" is added for every protocol delimiter between the
+// tapes and after the last one.
+func Fields(tapes []*bob.Tape) ([][]byte, error) {
+	var fields [][]byte
+	var prev *bob.Tape
+	for i, tape := range tapes {
+		if tape == nil {
+			return nil, fmt.Errorf("tape %d must be set", i)
+		}
+		for j := range tape.Cell {
+			c := (*bob.Cell)(&tape.Cell[j])
+			op, isOp := c.Opcode()
+			if fields == nil {
+				// everything before the OP_RETURN is not signed
+				if isOp && op == script.OpRETURN {
+					fields = [][]byte{{op}}
+					prev = tape
+				}
+				continue
+			}
+			if j == 0 && prev != tape && delimited(prev, tape) {
+				fields = append(fields, []byte(bob.ProtocolDelimiter))
+			}
+			prev = tape
+			if isOp {
+				fields = append(fields, []byte{op})
+				continue
+			}
+			data, err := c.Bytes()
+			if err != nil {
+				return nil, fmt.Errorf("tape %d cell %d: %w", i, j, err)
+			}
+			fields = append(fields, data)
+		}
+	}
+	if fields == nil {
+		return nil, fmt.Errorf("no OP_RETURN before the AIP tape")
+	}
+	return append(fields, []byte(bob.ProtocolDelimiter)), nil
+}
+
+// delimited returns true if the next tape followed a protocol delimiter,
+// by the gap it left in the cell indexes
+func delimited(prev, next *bob.Tape) bool {
+	if len(prev.Cell) == 0 || len(next.Cell) == 0 {
+		return true
+	}
+	return next.Cell[0].II-prev.Cell[len(prev.Cell)-1].II != 1
+}
+
+// Message returns the message signed by an AIP tape following the tapes,
+// made of all fields or only those at the given indexes
+func Message(tapes []*bob.Tape, indexes []int) ([]byte, error) {
+	fields, err := Fields(tapes)
+	if err != nil {
+		return nil, err
+	}
+	if len(indexes) == 0 {
+		return bytes.Join(fields, nil), nil
+	}
+
+	var message []byte
+	for _, index := range indexes {
+		if index < 0 || index >= len(fields) {
+			return nil, fmt.Errorf("field index %d is out of range (%d fields)", index, len(fields))
+		}
+		message = append(message, fields[index]...)
+	}
+	return message, nil
+}
+
+// twetchMessage returns the message as signed by Twetch: the hex of the
+// sha256 of all fields but the OP_RETURN and the last delimiter
+func twetchMessage(tapes []*bob.Tape) ([]byte, error) {
+	fields, err := Fields(tapes)
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256(bytes.Join(fields[1:len(fields)-1], nil))
+	return []byte(hex.EncodeToString(hash[:])), nil
+}
+
+// Verify checks the signature against the message rebuilt from the tapes
+// before the AIP tape (of the same output), setting Valid and RecoveredAddress
+//
+// An error is only returned if the signature can not be checked at all,
+// an invalid signature leaves Valid false.
+func (a *AIP) Verify(tapes []*bob.Tape) error {
+	a.Valid, a.RecoveredAddress = false, ""
+	if a.Algorithm != AlgorithmBitcoinECDSA {
+		return fmt.Errorf("unsupported algorithm %q", a.Algorithm)
+	}
+
+	message, err := Message(tapes, a.Indexes)
+	if err != nil {
+		return err
+	}
+	messages := [][]byte{message}
+	if len(a.Indexes) == 0 {
+		var twetch []byte
+		if twetch, err = twetchMessage(tapes); err != nil {
+			return err
+		}
+		messages = append(messages, twetch)
+	}
+
+	for _, m := range messages {
+		recovered, err := recoverAddress(a.Signature, m)
+		if err != nil {
+			return err
+		}
+		if a.RecoveredAddress == "" {
+			a.RecoveredAddress = recovered
+		}
+		if recovered == a.Address {
+			a.Valid, a.RecoveredAddress = true, recovered
+			return nil
+		}
+	}
+	return nil
+}
+
+// recoverAddress returns the address of the key that made the signature
+func recoverAddress(sig, message []byte) (string, error) {
+	pubKey, compressed, err := bsm.PubKeyFromSignature(sig, message)
+	if err != nil {
+		return "", fmt.Errorf("failed to recover the public key: %w", err)
+	}
+	address, err := script.NewAddressFromPublicKeyWithCompression(pubKey, true, compressed)
+	if err != nil {
+		return "", err
+	}
+	return address.AddressString, nil
+}
+
+// Decode is the bob.TapeDecoder of AIP tapes, decoding and verifying the
+// current tape against the tapes before it
+func Decode(ctx *bob.TapeContext) (any, error) {
+	a, err := NewFromTape(ctx.CurrentTape())
+	if err != nil {
+		return nil, err
+	}
+	if err = a.Verify(ctx.Tapes()[:ctx.Tape]); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Register registers the AIP decoder with the registry
+func Register(reg *bob.Registry) error {
+	return reg.Register(Prefix, Decode)
+}
@@ -0,0 +1,290 @@
+package aip
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/bitcoinschema/go-bob"
+	test "github.com/bitcoinschema/go-bob/testing"
+	bsm "github.com/bsv-blockchain/go-sdk/compat/bsm"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/bsv-blockchain/go-sdk/script"
+	"github.com/bsv-blockchain/go-sdk/transaction"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	rawBobTx = test.GetTestHex("../../testing/tx/2.hex")
+	bapTx    = test.GetTestHex("../../testing/tx/98a5f6ef18eaea188bdfdc048f89a48af82627a15a76fd53584975f28ab3cc39.hex")
+)
+
+// testOutputTapes parses the script and returns the tapes of its output
+func testOutputTapes(t testing.TB, s *script.Script) []*bob.Tape {
+	tx := transaction.NewTransaction()
+	tx.AddOutput(&transaction.TransactionOutput{LockingScript: s})
+	bobTx, err := bob.NewFromTx(tx)
+	require.NoError(t, err)
+	return bobTx.Tapes()
+}
+
+// testDataScript returns an OP_RETURN script with two protocols
+func testDataScript() *script.Script {
+	s := script.NewFromBytes([]byte{})
+	_ = s.AppendOpcodes(script.OpFALSE, script.OpRETURN)
+	_ = s.AppendPushDataString("1PuQa7K62MiKCtssSLKy1kh56WWU7MtUR5")
+	_ = s.AppendPushDataString("SET")
+	_ = s.AppendPushDataString("app")
+	_ = s.AppendPushDataString("test")
+	_ = s.AppendPushDataString(bob.ProtocolDelimiter)
+	_ = s.AppendPushDataString("data")
+	return s
+}
+
+// testSignedScript returns the data script with an AIP tape signed by the key
+func testSignedScript(t testing.TB, key *ec.PrivateKey, indexes []int) *script.Script {
+	s := testDataScript()
+	message, err := Message(testOutputTapes(t, s), indexes)
+	require.NoError(t, err)
+
+	var sig []byte
+	sig, err = bsm.SignMessage(key, message)
+	require.NoError(t, err)
+
+	_ = s.AppendPushDataString(bob.ProtocolDelimiter)
+	_ = s.AppendPushDataString(Prefix)
+	_ = s.AppendPushDataString(AlgorithmBitcoinECDSA)
+	_ = s.AppendPushDataString(test.Address(key).AddressString)
+	_ = s.AppendPushDataString(base64.StdEncoding.EncodeToString(sig))
+	for _, index := range indexes {
+		_ = s.AppendPushDataString(strconv.Itoa(index))
+	}
+	return s
+}
+
+// TestNewFromTape tests the method NewFromTape()
+func TestNewFromTape(t *testing.T) {
+	t.Parallel()
+
+	sig := make([]byte, compactSignatureLength)
+	sig[0] = 31
+	b64 := base64.StdEncoding.EncodeToString(sig)
+	address := "148WDH6nFWv5gH81wepCrk5fHkJwEPAQ4Q"
+
+	var (
+		// Testing signature encodings, indexes and invalid tapes
+		tests = []struct {
+			name            string
+			tape            *bob.Tape
+			expectedIndexes []int
+			expectedError   bool
+		}{
+			{"base64 signature", (*bob.Tape)(test.Tape(Prefix, AlgorithmBitcoinECDSA, address, b64)), nil, false},
+			{"raw signature", (*bob.Tape)(test.Tape(Prefix, AlgorithmBitcoinECDSA, address, string(sig))), nil, false},
+			{"decimal indexes", (*bob.Tape)(test.Tape(Prefix, AlgorithmBitcoinECDSA, address, b64, "0", "1", "12")), []int{0, 1, 12}, false},
+			{"binary index", (*bob.Tape)(test.Tape(Prefix, AlgorithmBitcoinECDSA, address, b64, "\x05")), []int{5}, false},
+			{"negative index", (*bob.Tape)(test.Tape(Prefix, AlgorithmBitcoinECDSA, address, b64, "\x81")), nil, true},
+			{"bad signature", (*bob.Tape)(test.Tape(Prefix, AlgorithmBitcoinECDSA, address, "signature")), nil, true},
+			{"too few cells", (*bob.Tape)(test.Tape(Prefix, AlgorithmBitcoinECDSA, address)), nil, true},
+			{"wrong prefix", (*bob.Tape)(test.Tape("1PuQa7K62MiKCtssSLKy1kh56WWU7MtUR5", AlgorithmBitcoinECDSA, address, b64)), nil, true},
+			{"nil", nil, nil, true},
+		}
+	)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a, err := NewFromTape(test.tape)
+			if test.expectedError {
+				require.Error(t, err)
+				require.Nil(t, a)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, AlgorithmBitcoinECDSA, a.Algorithm)
+			require.Equal(t, address, a.Address)
+			require.Equal(t, sig, a.Signature)
+			require.Equal(t, test.expectedIndexes, a.Indexes)
+			require.False(t, a.Valid)
+		})
+	}
+}
+
+// TestFields tests the method Fields()
+func TestFields(t *testing.T) {
+	t.Parallel()
+
+	tapes := testOutputTapes(t, testDataScript())
+	fields, err := Fields(tapes)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{
+		{script.OpRETURN},
+		[]byte("1PuQa7K62MiKCtssSLKy1kh56WWU7MtUR5"),
+		[]byte("SET"),
+		[]byte("app"),
+		[]byte("test"),
+		[]byte("|"),
+		[]byte("data"),
+		[]byte("|"),
+	}, fields)
+
+	var message []byte
+	message, err = Message(tapes, []int{0, 2, 3, 4})
+	require.NoError(t, err)
+	require.Equal(t, "jSETapptest", string(message))
+
+	_, err = Message(tapes, []int{8})
+	require.Error(t, err)
+
+	// no OP_RETURN
+	_, err = Fields([]*bob.Tape{(*bob.Tape)(test.Tape("data"))})
+	require.Error(t, err)
+	_, err = Fields([]*bob.Tape{nil})
+	require.Error(t, err)
+}
+
+// TestAIP_Verify tests the method Verify() on the fixtures
+func TestAIP_Verify(t *testing.T) {
+	t.Parallel()
+
+	var (
+		// Testing fixtures with an AIP tape
+		tests = []struct {
+			name            string
+			rawTx           string
+			expectedAddress string
+		}{
+			{"twetch", rawBobTx, "148WDH6nFWv5gH81wepCrk5fHkJwEPAQ4Q"},
+			{"bap attestation", bapTx, "134a6TXxzgQ9Az3w8BcvgdZyA5UqRL89da"},
+		}
+	)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			bobTx, err := bob.NewFromRawTxString(test.rawTx)
+			require.NoError(t, err)
+
+			tapes := bobTx.Tapes()
+			last := len(bobTx.Out[0].Tape) - 1
+			require.Equal(t, Prefix, mustPrefix(t, tapes[last]))
+
+			var a *AIP
+			a, err = NewFromTape(tapes[last])
+			require.NoError(t, err)
+			require.NoError(t, a.Verify(tapes[:last]))
+			require.True(t, a.Valid)
+			require.Equal(t, test.expectedAddress, a.RecoveredAddress)
+
+			// changing any signed data breaks the signature
+			s := "tampered"
+			tapes[last-1].Cell[1].S, tapes[last-1].Cell[1].H, tapes[last-1].Cell[1].B = &s, nil, nil
+			require.NoError(t, a.Verify(tapes[:last]))
+			require.False(t, a.Valid)
+			require.NotEmpty(t, a.RecoveredAddress)
+			require.NotEqual(t, test.expectedAddress, a.RecoveredAddress)
+		})
+	}
+}
+
+// mustPrefix returns the prefix of the tape, failing the test on error
+func mustPrefix(t testing.TB, tape *bob.Tape) string {
+	prefix, err := tape.Prefix()
+	require.NoError(t, err)
+	return prefix
+}
+
+// TestAIP_Verify_Indexes tests verifying signatures over selected fields
+func TestAIP_Verify_Indexes(t *testing.T) {
+	t.Parallel()
+
+	key := test.Key(42)
+	for _, indexes := range [][]int{nil, {0, 1, 2, 3, 4}, {6}} {
+		tapes := testOutputTapes(t, testSignedScript(t, key, indexes))
+		last := len(tapes) - 1
+
+		a, err := NewFromTape(tapes[last])
+		require.NoError(t, err)
+		require.Equal(t, indexes, a.Indexes)
+		require.NoError(t, a.Verify(tapes[:last]))
+		require.True(t, a.Valid)
+
+		// fields left out of the indexes are not covered
+		s := "changed"
+		tapes[1].Cell[0].S, tapes[1].Cell[0].H, tapes[1].Cell[0].B = &s, nil, nil
+		require.NoError(t, a.Verify(tapes[:last]))
+		require.Equal(t, len(indexes) == 1, a.Valid)
+	}
+}
+
+// TestAIP_Verify_Errors tests signatures that can not be checked
+func TestAIP_Verify_Errors(t *testing.T) {
+	t.Parallel()
+
+	tapes := testOutputTapes(t, testSignedScript(t, test.Key(42), []int{1}))
+	last := len(tapes) - 1
+
+	a, err := NewFromTape(tapes[last])
+	require.NoError(t, err)
+
+	a.Algorithm = "BITCOIN_SCHNORR"
+	require.Error(t, a.Verify(tapes[:last]))
+
+	a.Algorithm = AlgorithmBitcoinECDSA
+	a.Indexes = []int{100}
+	require.Error(t, a.Verify(tapes[:last]))
+	require.False(t, a.Valid)
+
+	a.Indexes = nil
+	require.Error(t, a.Verify(nil))
+}
+
+// TestRegister tests decoding a tx with the registered decoder
+func TestRegister(t *testing.T) {
+	t.Parallel()
+
+	bobTx, err := bob.NewFromRawTxString(rawBobTx)
+	require.NoError(t, err)
+
+	reg := bob.NewRegistry()
+	require.NoError(t, Register(reg))
+
+	results := bobTx.Decode(reg)
+	require.Empty(t, results.Errors())
+
+	signatures := bob.ValuesOf[*AIP](results)
+	require.Len(t, signatures, 1)
+	require.True(t, signatures[0].Valid)
+	require.Equal(t, "148WDH6nFWv5gH81wepCrk5fHkJwEPAQ4Q", signatures[0].RecoveredAddress)
+}
+
+// ExampleAIP_Verify example using Verify()
+func ExampleAIP_Verify() {
+	bobTx, err := bob.NewFromRawTxString(rawBobTx)
+	if err != nil {
+		fmt.Printf("error occurred: %s", err.Error())
+		return
+	}
+
+	tapes := bobTx.Tapes()
+	var a *AIP
+	if a, err = NewFromTape(tapes[3]); err != nil {
+		fmt.Printf("error occurred: %s", err.Error())
+		return
+	}
+	if err = a.Verify(tapes[:3]); err != nil {
+		fmt.Printf("error occurred: %s", err.Error())
+		return
+	}
+	fmt.Printf("signed by %s: %t", a.RecoveredAddress, a.Valid)
+	// Output:signed by 148WDH6nFWv5gH81wepCrk5fHkJwEPAQ4Q: true
+}
+
+// BenchmarkAIP_Verify benchmarks the method Verify()
+func BenchmarkAIP_Verify(b *testing.B) {
+	bobTx, _ := bob.NewFromRawTxString(rawBobTx)
+	tapes := bobTx.Tapes()
+	a, _ := NewFromTape(tapes[3])
+	for i := 0; i < b.N; i++ {
+		_ = a.Verify(tapes[:3])
+	}
+}
@@ -0,0 +1,78 @@
+package aip
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+
+	"github.com/bitcoinschema/go-bob"
+	"github.com/bitcoinschema/go-bpu"
+	bsm "github.com/bsv-blockchain/go-sdk/compat/bsm"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/bsv-blockchain/go-sdk/script"
+)
+
+// Sign signs the tapes of an output with the key and returns them with an
+// AIP tape appended, signing only the fields at the given indexes if any
+//
+// The message is built with Message, the same way Verify does. The AIP
+// tape follows a protocol delimiter, so ToTx puts a "|" before it.
+func Sign(tapes []bpu.Tape, key *ec.PrivateKey, indexes ...int) ([]bpu.Tape, error) {
+	if key == nil {
+		return nil, fmt.Errorf("private key must be set")
+	}
+
+	ptrs := make([]*bob.Tape, len(tapes))
+	for i := range tapes {
+		ptrs[i] = (*bob.Tape)(&tapes[i])
+	}
+	message, err := Message(ptrs, indexes)
+	if err != nil {
+		return nil, err
+	}
+
+	var sig []byte
+	if sig, err = bsm.SignMessage(key, message); err != nil {
+		return nil, fmt.Errorf("failed to sign message: %w", err)
+	}
+
+	var address *script.Address
+	if address, err = script.NewAddressFromPublicKey(key.PubKey(), true); err != nil {
+		return nil, err
+	}
+
+	values := []string{Prefix, AlgorithmBitcoinECDSA, address.AddressString, base64.StdEncoding.EncodeToString(sig)}
+	for _, index := range indexes {
+		values = append(values, strconv.Itoa(index))
+	}
+
+	// leave a gap in the cell indexes for the delimiter
+	ii := lastCellIndex(tapes) + 2
+	tape := bpu.Tape{I: uint8(len(tapes))}
+	for i, value := range values {
+		tape.Cell = append(tape.Cell, newCell([]byte(value), uint8(i), ii+uint8(i)))
+	}
+
+	signed := make([]bpu.Tape, 0, len(tapes)+1)
+	signed = append(signed, tapes...)
+	return append(signed, tape), nil
+}
+
+// lastCellIndex returns the chunk index (II) of the last cell of the tapes
+func lastCellIndex(tapes []bpu.Tape) uint8 {
+	for i := len(tapes) - 1; i >= 0; i-- {
+		if l := len(tapes[i].Cell); l > 0 {
+			return tapes[i].Cell[l-1].II
+		}
+	}
+	return 0
+}
+
+// newCell returns a pushdata cell the way bpu creates them
+func newCell(data []byte, i, ii uint8) bpu.Cell {
+	s := string(data)
+	h := hex.EncodeToString(data)
+	b := base64.StdEncoding.EncodeToString(data)
+	return bpu.Cell{S: &s, H: &h, B: &b, I: i, II: ii}
+}
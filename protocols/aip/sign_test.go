@@ -0,0 +1,141 @@
+package aip
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/bitcoinschema/go-bob"
+	test "github.com/bitcoinschema/go-bob/testing"
+	"github.com/bitcoinschema/go-bpu"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/bsv-blockchain/go-sdk/transaction"
+	"github.com/stretchr/testify/require"
+)
+
+// testDataTx returns a BOB tx with the data script as its only output
+func testDataTx(t testing.TB) *bob.Tx {
+	tx := transaction.NewTransaction()
+	tx.AddOutput(&transaction.TransactionOutput{LockingScript: testDataScript()})
+	bobTx, err := bob.NewFromTx(tx)
+	require.NoError(t, err)
+	return bobTx
+}
+
+// TestSign tests that signed outputs survive ToTx and FromRawTxString and still verify
+func TestSign(t *testing.T) {
+	t.Parallel()
+
+	key := test.Key(42)
+	for _, indexes := range [][]int{nil, {0, 1, 2, 3, 4}, {6}} {
+		t.Run(fmt.Sprint(indexes), func(t *testing.T) {
+			bobTx := testDataTx(t)
+
+			signed, err := Sign(bobTx.Out[0].Tape, key, indexes...)
+			require.NoError(t, err)
+			require.Len(t, signed, len(bobTx.Out[0].Tape)+1)
+			bobTx.Out[0].Tape = signed
+
+			var tx *transaction.Transaction
+			tx, err = bobTx.ToTx()
+			require.NoError(t, err)
+			require.Equal(t, testSignedScript(t, key, indexes).Bytes(), tx.Outputs[0].LockingScript.Bytes())
+
+			var parsed *bob.Tx
+			parsed, err = bob.NewFromRawTxString(tx.String())
+			require.NoError(t, err)
+
+			reg := bob.NewRegistry()
+			require.NoError(t, Register(reg))
+			results := parsed.Decode(reg)
+			require.Empty(t, results.Errors())
+
+			signatures := bob.ValuesOf[*AIP](results)
+			require.Len(t, signatures, 1)
+			require.True(t, signatures[0].Valid)
+			require.Equal(t, test.Address(test.Key(42)).AddressString, signatures[0].RecoveredAddress)
+			require.Equal(t, indexes, signatures[0].Indexes)
+
+			// the signed tapes verify before being rebuilt as well
+			tapes := bobTx.Tapes()
+			var a *AIP
+			a, err = NewFromTape(tapes[len(tapes)-1])
+			require.NoError(t, err)
+			require.NoError(t, a.Verify(tapes[:len(tapes)-1]))
+			require.True(t, a.Valid)
+		})
+	}
+}
+
+// TestSign_Twice tests signing an output that already has an AIP tape
+func TestSign_Twice(t *testing.T) {
+	t.Parallel()
+
+	bobTx := testDataTx(t)
+	signed, err := Sign(bobTx.Out[0].Tape, test.Key(42))
+	require.NoError(t, err)
+	signed, err = Sign(signed, test.Key(42), 1)
+	require.NoError(t, err)
+	bobTx.Out[0].Tape = signed
+
+	var rawTx string
+	rawTx, err = bobTx.ToRawTxString()
+	require.NoError(t, err)
+
+	var parsed *bob.Tx
+	parsed, err = bob.NewFromRawTxString(rawTx)
+	require.NoError(t, err)
+
+	reg := bob.NewRegistry()
+	require.NoError(t, Register(reg))
+	signatures := bob.ValuesOf[*AIP](parsed.Decode(reg))
+	require.Len(t, signatures, 2)
+	require.True(t, signatures[0].Valid)
+	require.True(t, signatures[1].Valid)
+}
+
+// TestSign_Errors tests tapes that can not be signed
+func TestSign_Errors(t *testing.T) {
+	t.Parallel()
+
+	bobTx := testDataTx(t)
+
+	_, err := Sign(bobTx.Out[0].Tape, nil)
+	require.Error(t, err)
+
+	_, err = Sign(bobTx.Out[0].Tape, test.Key(42), 100)
+	require.Error(t, err)
+
+	_, err = Sign([]bpu.Tape{*test.Tape("data")}, test.Key(42))
+	require.Error(t, err)
+
+	// the original tapes are not modified
+	require.Len(t, bobTx.Out[0].Tape, 3)
+}
+
+// ExampleSign example using Sign()
+func ExampleSign() {
+	tx := transaction.NewTransaction()
+	tx.AddOutput(&transaction.TransactionOutput{LockingScript: testDataScript()})
+	bobTx, err := bob.NewFromTx(tx)
+	if err != nil {
+		fmt.Printf("error occurred: %s", err.Error())
+		return
+	}
+
+	key, _ := ec.PrivateKeyFromHex(fmt.Sprintf("%064x", 42))
+	if bobTx.Out[0].Tape, err = Sign(bobTx.Out[0].Tape, key); err != nil {
+		fmt.Printf("error occurred: %s", err.Error())
+		return
+	}
+	fmt.Printf("found tapes: %d", len(bobTx.Out[0].Tape))
+	// Output:found tapes: 4
+}
+
+// BenchmarkSign benchmarks the method Sign()
+func BenchmarkSign(b *testing.B) {
+	bobTx := testDataTx(b)
+	key := test.Key(42)
+	for i := 0; i < b.N; i++ {
+		_, _ = Sign(bobTx.Out[0].Tape, key)
+	}
+}
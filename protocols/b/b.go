@@ -0,0 +1,156 @@
+// Package b decodes B:// file uploads from BOB tapes
+//
+// Specs: https://b.bitdb.network/
+//
+// A B:// tape is made of the prefix, the content, the media type, the
+// encoding and an optional filename:
+//
+//	19HxigV4QyBv3tHpQVcUEQyq1pzZVdoAut [Data] [Media Type] [Encoding] [Filename]
+package b
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bitcoinschema/go-bob"
+)
+
+// Prefix is the Bitcom address of the B:// protocol
+const Prefix = "19HxigV4QyBv3tHpQVcUEQyq1pzZVdoAut"
+
+// Encoding is the encoding of the content of a B:// tape
+type Encoding string
+
+// Supported encodings
+const (
+	// EncodingBinary is raw binary content
+	EncodingBinary Encoding = "binary"
+
+	// EncodingUTF8 is text content, "utf8" and "text" are accepted as well
+	EncodingUTF8 Encoding = "utf-8"
+
+	// EncodingBase64 is content pushed as base64 text
+	EncodingBase64 Encoding = "base64"
+
+	// EncodingHex is content pushed as hex text
+	EncodingHex Encoding = "hex"
+)
+
+// B is a decoded B:// file upload
+type B struct {
+	Content   []byte   `json:"content"`
+	MediaType string   `json:"media_type"`
+	Encoding  Encoding `json:"encoding"`
+	Filename  string   `json:"filename,omitempty"`
+}
+
+// ParseEncoding returns the encoding of an encoding cell, ignoring case
+func ParseEncoding(s string) (Encoding, error) {
+	switch strings.ToLower(s) {
+	case string(EncodingBinary):
+		return EncodingBinary, nil
+	case string(EncodingUTF8), "utf8", "text":
+		return EncodingUTF8, nil
+	case string(EncodingBase64):
+		return EncodingBase64, nil
+	case string(EncodingHex):
+		return EncodingHex, nil
+	}
+	return "", fmt.Errorf("unknown encoding %q", s)
+}
+
+// NewFromTape decodes a B:// tape
+//
+// Content is the decoded file: base64 and hex content is decoded from its
+// text, utf-8 content must be valid UTF-8.
+func NewFromTape(tape *bob.Tape) (*B, error) {
+	if tape == nil {
+		return nil, fmt.Errorf("tape must be set")
+	}
+	if l := len(tape.Cell); l < 4 || l > 5 {
+		return nil, fmt.Errorf("B:// tape must have 4 or 5 cells, got %d", l)
+	}
+
+	prefix, err := tape.Prefix()
+	if err != nil {
+		return nil, err
+	}
+	if prefix != Prefix {
+		return nil, fmt.Errorf("tape prefix %q is not %s", prefix, Prefix)
+	}
+
+	var fields [4]string
+	for i := range fields {
+		if i+1 == len(tape.Cell) {
+			break
+		}
+		var c *bob.Cell
+		if c, err = tape.CellAt(i + 1); err != nil {
+			return nil, err
+		}
+		if fields[i], err = c.String(); err != nil {
+			return nil, fmt.Errorf("cell %d: %w", i+1, err)
+		}
+	}
+
+	b := &B{MediaType: fields[1], Filename: fields[3]}
+	if b.MediaType == "" {
+		return nil, fmt.Errorf("media type must be set")
+	}
+	if b.Encoding, err = ParseEncoding(fields[2]); err != nil {
+		return nil, err
+	}
+	if b.Content, err = decodeContent(fields[0], b.Encoding); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// decodeContent returns the file of the content cell for the encoding
+func decodeContent(s string, encoding Encoding) ([]byte, error) {
+	switch encoding {
+	case EncodingUTF8:
+		if !utf8.ValidString(s) {
+			return nil, fmt.Errorf("content is not valid utf-8")
+		}
+	case EncodingBase64:
+		content, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return nil, fmt.Errorf("content is not valid base64: %w", err)
+		}
+		return content, nil
+	case EncodingHex:
+		content, err := hex.DecodeString(s)
+		if err != nil {
+			return nil, fmt.Errorf("content is not valid hex: %w", err)
+		}
+		return content, nil
+	}
+	return []byte(s), nil
+}
+
+// String returns the content as text for utf-8 content, or base64 otherwise
+func (b *B) String() string {
+	if b.Encoding == EncodingUTF8 {
+		return string(b.Content)
+	}
+	return base64.StdEncoding.EncodeToString(b.Content)
+}
+
+// DataURI returns the content as a data URI
+func (b *B) DataURI() string {
+	return "data:" + b.MediaType + ";base64," + base64.StdEncoding.EncodeToString(b.Content)
+}
+
+// Decode is the bob.TapeDecoder of B:// tapes
+func Decode(ctx *bob.TapeContext) (any, error) {
+	return NewFromTape(ctx.CurrentTape())
+}
+
+// Register registers the B:// decoder with the registry
+func Register(reg *bob.Registry) error {
+	return reg.Register(Prefix, Decode)
+}
@@ -0,0 +1,159 @@
+package b
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/bitcoinschema/go-bob"
+	test "github.com/bitcoinschema/go-bob/testing"
+	"github.com/bitcoinschema/go-bpu"
+	"github.com/stretchr/testify/require"
+)
+
+var rawBobTx = test.GetTestHex("../../testing/tx/2.hex")
+
+// TestNewFromTape tests the method NewFromTape()
+func TestNewFromTape(t *testing.T) {
+	t.Parallel()
+
+	var (
+		// Testing encodings and invalid tapes
+		tests = []struct {
+			name          string
+			tape          *bob.Tape
+			expected      *B
+			expectedError bool
+		}{
+			{
+				"utf-8 with filename",
+				(*bob.Tape)(test.Tape(Prefix, "hello", "text/plain", "utf-8", "hello.txt")),
+				&B{Content: []byte("hello"), MediaType: "text/plain", Encoding: EncodingUTF8, Filename: "hello.txt"},
+				false,
+			},
+			{
+				"utf8 alias",
+				(*bob.Tape)(test.Tape(Prefix, "hello", "text/plain", "UTF8")),
+				&B{Content: []byte("hello"), MediaType: "text/plain", Encoding: EncodingUTF8},
+				false,
+			},
+			{
+				"binary",
+				(*bob.Tape)(test.Tape(Prefix, "\x00\xff", "application/octet-stream", "binary")),
+				&B{Content: []byte{0x00, 0xff}, MediaType: "application/octet-stream", Encoding: EncodingBinary},
+				false,
+			},
+			{
+				"base64",
+				(*bob.Tape)(test.Tape(Prefix, "aGVsbG8=", "text/plain", "base64")),
+				&B{Content: []byte("hello"), MediaType: "text/plain", Encoding: EncodingBase64},
+				false,
+			},
+			{
+				"hex",
+				(*bob.Tape)(test.Tape(Prefix, "68656c6c6f", "text/plain", "hex", "hello.txt")),
+				&B{Content: []byte("hello"), MediaType: "text/plain", Encoding: EncodingHex, Filename: "hello.txt"},
+				false,
+			},
+			{"invalid utf-8", (*bob.Tape)(test.Tape(Prefix, "\xff", "text/plain", "utf-8")), nil, true},
+			{"invalid base64", (*bob.Tape)(test.Tape(Prefix, "!!", "text/plain", "base64")), nil, true},
+			{"invalid hex", (*bob.Tape)(test.Tape(Prefix, "zz", "text/plain", "hex")), nil, true},
+			{"unknown encoding", (*bob.Tape)(test.Tape(Prefix, "hello", "text/plain", "gzip")), nil, true},
+			{"missing media type", (*bob.Tape)(test.Tape(Prefix, "hello", "", "utf-8")), nil, true},
+			{"too few cells", (*bob.Tape)(test.Tape(Prefix, "hello", "text/plain")), nil, true},
+			{"too many cells", (*bob.Tape)(test.Tape(Prefix, "hello", "text/plain", "utf-8", "a.txt", "extra")), nil, true},
+			{"wrong prefix", (*bob.Tape)(test.Tape("1PuQa7K62MiKCtssSLKy1kh56WWU7MtUR5", "hello", "text/plain", "utf-8")), nil, true},
+			{"empty cell", &bob.Tape{Cell: []bpu.Cell{(*bob.Tape)(test.Tape(Prefix)).Cell[0], {}, {}, {}}}, nil, true},
+			{"nil", nil, nil, true},
+		}
+	)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b, err := NewFromTape(test.tape)
+			if test.expectedError {
+				require.Error(t, err)
+				require.Nil(t, b)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expected, b)
+		})
+	}
+}
+
+// TestParseEncoding tests the method ParseEncoding()
+func TestParseEncoding(t *testing.T) {
+	t.Parallel()
+
+	for s, expected := range map[string]Encoding{
+		"binary": EncodingBinary,
+		"utf-8":  EncodingUTF8,
+		"utf8":   EncodingUTF8,
+		"text":   EncodingUTF8,
+		"BASE64": EncodingBase64,
+		"hex":    EncodingHex,
+	} {
+		encoding, err := ParseEncoding(s)
+		require.NoError(t, err)
+		require.Equal(t, expected, encoding)
+	}
+
+	_, err := ParseEncoding("")
+	require.Error(t, err)
+}
+
+// TestB_String tests the methods String() and DataURI()
+func TestB_String(t *testing.T) {
+	t.Parallel()
+
+	b := &B{Content: []byte("hello"), MediaType: "text/plain", Encoding: EncodingUTF8}
+	require.Equal(t, "hello", b.String())
+	require.Equal(t, "data:text/plain;base64,aGVsbG8=", b.DataURI())
+
+	b.Encoding = EncodingBinary
+	require.Equal(t, "aGVsbG8=", b.String())
+}
+
+// TestRegister tests decoding a tx with the registered decoder
+func TestRegister(t *testing.T) {
+	t.Parallel()
+
+	bobTx, err := bob.NewFromRawTxString(rawBobTx)
+	require.NoError(t, err)
+
+	reg := bob.NewRegistry()
+	require.NoError(t, Register(reg))
+	require.Error(t, Register(reg))
+
+	results := bobTx.Decode(reg)
+	require.Empty(t, results.Errors())
+
+	files := bob.ValuesOf[*B](results)
+	require.Len(t, files, 1)
+	require.Equal(t, &B{Content: []byte(" "), MediaType: "text/plain", Encoding: EncodingUTF8, Filename: "twetch.txt"}, files[0])
+}
+
+// ExampleNewFromTape example using NewFromTape()
+func ExampleNewFromTape() {
+	bobTx, err := bob.NewFromRawTxString(rawBobTx)
+	if err != nil {
+		fmt.Printf("error occurred: %s", err.Error())
+		return
+	}
+
+	var b *B
+	if b, err = NewFromTape(bobTx.TapeByPrefix(Prefix)); err != nil {
+		fmt.Printf("error occurred: %s", err.Error())
+		return
+	}
+	fmt.Printf("found file: %s (%s)", b.Filename, b.MediaType)
+	// Output:found file: twetch.txt (text/plain)
+}
+
+// BenchmarkNewFromTape benchmarks the method NewFromTape()
+func BenchmarkNewFromTape(b *testing.B) {
+	tape := (*bob.Tape)(test.Tape(Prefix, "aGVsbG8=", "text/plain", "base64", "hello.txt"))
+	for i := 0; i < b.N; i++ {
+		_, _ = NewFromTape(tape)
+	}
+}
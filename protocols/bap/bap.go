@@ -0,0 +1,154 @@
+// Package bap decodes BAP (Bitcoin Attestation Protocol) tapes
+//
+// Specs: https://github.com/icellan/bap
+//
+// A BAP tape is made of the prefix, the record type and its fields, and is
+// followed by an AIP tape signing it:
+//
+//	1BAPSuaPnfGnSBM3GLV9yhxUdYe4vGbdMT ID <identity key> <address>
+//	1BAPSuaPnfGnSBM3GLV9yhxUdYe4vGbdMT ATTEST <attestation hash> <sequence>
+//	1BAPSuaPnfGnSBM3GLV9yhxUdYe4vGbdMT REVOKE <attestation hash> <sequence>
+//	1BAPSuaPnfGnSBM3GLV9yhxUdYe4vGbdMT ALIAS <identity key> <profile json>
+package bap
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/bitcoinschema/go-bob"
+	"github.com/bitcoinschema/go-bob/protocols/aip"
+	"github.com/bsv-blockchain/go-sdk/script"
+)
+
+// Prefix is the Bitcom address of the BAP protocol
+const Prefix = "1BAPSuaPnfGnSBM3GLV9yhxUdYe4vGbdMT"
+
+// Type is the type of a BAP record
+type Type string
+
+// BAP record types
+const (
+	TypeID     Type = "ID"
+	TypeAttest Type = "ATTEST"
+	TypeRevoke Type = "REVOKE"
+	TypeAlias  Type = "ALIAS"
+)
+
+// BAP is a decoded BAP record
+//
+// IDKey is set for ID and ALIAS records, Address for ID records, Hash and
+// Sequence for ATTEST and REVOKE records and Profile for ALIAS records.
+// Signer is the AIP tape following the record in the same output.
+type BAP struct {
+	Type     Type            `json:"type"`
+	IDKey    string          `json:"id_key,omitempty"`
+	Address  string          `json:"address,omitempty"`
+	Hash     string          `json:"hash,omitempty"`
+	Sequence uint64          `json:"sequence"`
+	Profile  json.RawMessage `json:"profile,omitempty"`
+	Signer   *aip.AIP        `json:"signer,omitempty"`
+}
+
+// NewFromTape decodes a BAP tape, without its signer
+func NewFromTape(tape *bob.Tape) (*BAP, error) {
+	if tape == nil {
+		return nil, fmt.Errorf("tape must be set")
+	}
+	if len(tape.Cell) != 4 {
+		return nil, fmt.Errorf("BAP tape must have 4 cells, got %d", len(tape.Cell))
+	}
+
+	var fields [4]string
+	for i := range fields {
+		c, err := tape.CellAt(i)
+		if err != nil {
+			return nil, err
+		}
+		if fields[i], err = c.String(); err != nil {
+			return nil, fmt.Errorf("cell %d: %w", i, err)
+		}
+	}
+	if fields[0] != Prefix {
+		return nil, fmt.Errorf("tape prefix %q is not %s", fields[0], Prefix)
+	}
+
+	b := &BAP{Type: Type(fields[1])}
+	switch b.Type {
+	case TypeID:
+		if fields[2] == "" {
+			return nil, fmt.Errorf("%s identity key must be set", b.Type)
+		}
+		if _, err := script.NewAddressFromString(fields[3]); err != nil {
+			return nil, fmt.Errorf("%s address %q is invalid: %w", b.Type, fields[3], err)
+		}
+		b.IDKey, b.Address = fields[2], fields[3]
+	case TypeAttest, TypeRevoke:
+		if h, err := hex.DecodeString(fields[2]); err != nil || len(h) != 32 {
+			return nil, fmt.Errorf("%s attestation hash %q is not a sha256 hash", b.Type, fields[2])
+		}
+		sequence, err := strconv.ParseUint(fields[3], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s sequence %q is invalid: %w", b.Type, fields[3], err)
+		}
+		b.Hash, b.Sequence = fields[2], sequence
+	case TypeAlias:
+		if fields[2] == "" {
+			return nil, fmt.Errorf("%s identity key must be set", b.Type)
+		}
+		if !json.Valid([]byte(fields[3])) {
+			return nil, fmt.Errorf("%s profile is not valid json", b.Type)
+		}
+		b.IDKey, b.Profile = fields[2], json.RawMessage(fields[3])
+	default:
+		return nil, fmt.Errorf("unknown type %q", fields[1])
+	}
+	return b, nil
+}
+
+// NewFromTapes decodes the BAP tape at the index of the tapes of an output
+// and links it to the first AIP tape after it, verifying its signature
+func NewFromTapes(tapes []*bob.Tape, index int) (*BAP, error) {
+	if index < 0 || index >= len(tapes) {
+		return nil, fmt.Errorf("tape %d is out of range (%d tapes)", index, len(tapes))
+	}
+	b, err := NewFromTape(tapes[index])
+	if err != nil {
+		return nil, err
+	}
+
+	for i := index + 1; i < len(tapes); i++ {
+		if prefix, err := tapes[i].Prefix(); err != nil || prefix != aip.Prefix {
+			continue
+		}
+		if b.Signer, err = aip.NewFromTape(tapes[i]); err != nil {
+			return nil, fmt.Errorf("signer: %w", err)
+		}
+		if err = b.Signer.Verify(tapes[:i]); err != nil {
+			return nil, fmt.Errorf("signer: %w", err)
+		}
+		break
+	}
+	return b, nil
+}
+
+// SignerAddress returns the address that signed the record, or an empty
+// string if it has no valid AIP signature
+func (b *BAP) SignerAddress() string {
+	if b.Signer == nil || !b.Signer.Valid {
+		return ""
+	}
+	return b.Signer.RecoveredAddress
+}
+
+// Decode is the bob.TapeDecoder of BAP tapes, linking the record to the
+// AIP signer of the output
+func Decode(ctx *bob.TapeContext) (any, error) {
+	return NewFromTapes(ctx.Tapes(), ctx.Tape)
+}
+
+// Register registers the BAP decoder with the registry
+func Register(reg *bob.Registry) error {
+	return reg.Register(Prefix, Decode)
+}
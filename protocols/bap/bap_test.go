@@ -0,0 +1,190 @@
+package bap
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/bitcoinschema/go-bob"
+	"github.com/bitcoinschema/go-bob/protocols/aip"
+	test "github.com/bitcoinschema/go-bob/testing"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/bsv-blockchain/go-sdk/script"
+	"github.com/bsv-blockchain/go-sdk/transaction"
+	"github.com/stretchr/testify/require"
+)
+
+const testHash = "6386afa223e54d4f955e44a1ef4ae5b18bbb8689dff078627a7cb842fad4f7c6"
+
+var bapTx = test.GetTestHex("../../testing/tx/98a5f6ef18eaea188bdfdc048f89a48af82627a15a76fd53584975f28ab3cc39.hex")
+
+// testRecordTx returns a tx with an output holding the BAP record, signed by the key if set
+func testRecordTx(t testing.TB, key *ec.PrivateKey, values ...string) *bob.Tx {
+	s := script.NewFromBytes([]byte{})
+	_ = s.AppendOpcodes(script.OpFALSE, script.OpRETURN)
+	_ = s.AppendPushDataString(Prefix)
+	for _, value := range values {
+		_ = s.AppendPushDataString(value)
+	}
+	tx := transaction.NewTransaction()
+	tx.AddOutput(&transaction.TransactionOutput{LockingScript: s})
+
+	bobTx, err := bob.NewFromTx(tx)
+	require.NoError(t, err)
+	if key != nil {
+		bobTx.Out[0].Tape, err = aip.Sign(bobTx.Out[0].Tape, key)
+		require.NoError(t, err)
+	}
+	return bobTx
+}
+
+// TestNewFromTape tests the method NewFromTape()
+func TestNewFromTape(t *testing.T) {
+	t.Parallel()
+
+	address := "134a6TXxzgQ9Az3w8BcvgdZyA5UqRL89da"
+	profile := `{"@type":"Person","name":"Satoshi"}`
+
+	var (
+		// Testing every record type and invalid tapes
+		tests = []struct {
+			name          string
+			tape          *bob.Tape
+			expected      *BAP
+			expectedError bool
+		}{
+			{"id", (*bob.Tape)(test.Tape(Prefix, "ID", "idkey", address)), &BAP{Type: TypeID, IDKey: "idkey", Address: address}, false},
+			{"attest", (*bob.Tape)(test.Tape(Prefix, "ATTEST", testHash, "0")), &BAP{Type: TypeAttest, Hash: testHash}, false},
+			{"revoke", (*bob.Tape)(test.Tape(Prefix, "REVOKE", testHash, "12")), &BAP{Type: TypeRevoke, Hash: testHash, Sequence: 12}, false},
+			{"alias", (*bob.Tape)(test.Tape(Prefix, "ALIAS", "idkey", profile)), &BAP{Type: TypeAlias, IDKey: "idkey", Profile: json.RawMessage(profile)}, false},
+			{"id without key", (*bob.Tape)(test.Tape(Prefix, "ID", "", address)), nil, true},
+			{"id with bad address", (*bob.Tape)(test.Tape(Prefix, "ID", "idkey", "address")), nil, true},
+			{"attest with bad hash", (*bob.Tape)(test.Tape(Prefix, "ATTEST", "abcd", "0")), nil, true},
+			{"attest with bad sequence", (*bob.Tape)(test.Tape(Prefix, "ATTEST", testHash, "-1")), nil, true},
+			{"alias with bad profile", (*bob.Tape)(test.Tape(Prefix, "ALIAS", "idkey", "{")), nil, true},
+			{"alias without key", (*bob.Tape)(test.Tape(Prefix, "ALIAS", "", profile)), nil, true},
+			{"unknown type", (*bob.Tape)(test.Tape(Prefix, "DATA", "a", "b")), nil, true},
+			{"too few cells", (*bob.Tape)(test.Tape(Prefix, "ATTEST", testHash)), nil, true},
+			{"wrong prefix", (*bob.Tape)(test.Tape(aip.Prefix, "ATTEST", testHash, "0")), nil, true},
+			{"nil", nil, nil, true},
+		}
+	)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b, err := NewFromTape(test.tape)
+			if test.expectedError {
+				require.Error(t, err)
+				require.Nil(t, b)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expected, b)
+		})
+	}
+}
+
+// TestNewFromTapes tests linking records to their AIP signer
+func TestNewFromTapes(t *testing.T) {
+	t.Parallel()
+
+	t.Run("fixture", func(t *testing.T) {
+		bobTx, err := bob.NewFromRawTxString(bapTx)
+		require.NoError(t, err)
+
+		var b *BAP
+		b, err = NewFromTapes(bobTx.Tapes(), 1)
+		require.NoError(t, err)
+		require.Equal(t, TypeAttest, b.Type)
+		require.Equal(t, testHash, b.Hash)
+		require.Equal(t, uint64(0), b.Sequence)
+		require.NotNil(t, b.Signer)
+		require.True(t, b.Signer.Valid)
+		require.Equal(t, "134a6TXxzgQ9Az3w8BcvgdZyA5UqRL89da", b.SignerAddress())
+	})
+
+	t.Run("signed id", func(t *testing.T) {
+		root, signing := test.Key(1), test.Key(2)
+		bobTx := testRecordTx(t, root, "ID", "idkey", test.Address(signing).AddressString)
+
+		b, err := NewFromTapes(bobTx.Tapes(), 1)
+		require.NoError(t, err)
+		require.Equal(t, test.Address(signing).AddressString, b.Address)
+		require.Equal(t, test.Address(root).AddressString, b.SignerAddress())
+
+		// tampering with the record breaks the link
+		s := "other"
+		bobTx.Out[0].Tape[1].Cell[2].S, bobTx.Out[0].Tape[1].Cell[2].H, bobTx.Out[0].Tape[1].Cell[2].B = &s, nil, nil
+		b, err = NewFromTapes(bobTx.Tapes(), 1)
+		require.NoError(t, err)
+		require.NotNil(t, b.Signer)
+		require.False(t, b.Signer.Valid)
+		require.Empty(t, b.SignerAddress())
+	})
+
+	t.Run("unsigned", func(t *testing.T) {
+		bobTx := testRecordTx(t, nil, "ATTEST", testHash, "1")
+		b, err := NewFromTapes(bobTx.Tapes(), 1)
+		require.NoError(t, err)
+		require.Nil(t, b.Signer)
+		require.Empty(t, b.SignerAddress())
+	})
+
+	t.Run("bad signer", func(t *testing.T) {
+		bobTx := testRecordTx(t, nil, "ATTEST", testHash, "1")
+		tapes := append(bobTx.Tapes(), (*bob.Tape)(test.Tape(aip.Prefix, aip.AlgorithmBitcoinECDSA)))
+		_, err := NewFromTapes(tapes, 1)
+		require.Error(t, err)
+	})
+
+	t.Run("out of range", func(t *testing.T) {
+		_, err := NewFromTapes(nil, 0)
+		require.Error(t, err)
+	})
+}
+
+// TestRegister tests decoding a tx with the registered decoder
+func TestRegister(t *testing.T) {
+	t.Parallel()
+
+	bobTx, err := bob.NewFromRawTxString(bapTx)
+	require.NoError(t, err)
+
+	reg := bob.NewRegistry()
+	require.NoError(t, Register(reg))
+	require.NoError(t, aip.Register(reg))
+
+	results := bobTx.Decode(reg)
+	require.Empty(t, results.Errors())
+	require.Len(t, results, 2)
+
+	records := bob.ValuesOf[*BAP](results)
+	require.Len(t, records, 1)
+	require.Equal(t, "134a6TXxzgQ9Az3w8BcvgdZyA5UqRL89da", records[0].SignerAddress())
+}
+
+// ExampleNewFromTapes example using NewFromTapes()
+func ExampleNewFromTapes() {
+	bobTx, err := bob.NewFromRawTxString(bapTx)
+	if err != nil {
+		fmt.Printf("error occurred: %s", err.Error())
+		return
+	}
+
+	var b *BAP
+	if b, err = NewFromTapes(bobTx.Tapes(), 1); err != nil {
+		fmt.Printf("error occurred: %s", err.Error())
+		return
+	}
+	fmt.Printf("%s by %s", b.Type, b.SignerAddress())
+	// Output:ATTEST by 134a6TXxzgQ9Az3w8BcvgdZyA5UqRL89da
+}
+
+// BenchmarkNewFromTapes benchmarks the method NewFromTapes()
+func BenchmarkNewFromTapes(b *testing.B) {
+	bobTx, _ := bob.NewFromRawTxString(bapTx)
+	tapes := bobTx.Tapes()
+	for i := 0; i < b.N; i++ {
+		_, _ = NewFromTapes(tapes, 1)
+	}
+}
@@ -0,0 +1,134 @@
+// Package bitcom decodes Bitcom "$" command tapes and replays them into the
+// state of every Bitcom address
+//
+// Specs: https://bitcom.bitdb.network/
+//
+// Commands are issued by the address of the first input of the tx:
+//
+//	$ echo <content> to <filename>
+//	$ echo <content> > <filename>
+//	$ echo <content> >> <filename>
+//	$ su <key>
+//	$ route add <bitcom address> <path> <endpoint>
+//	$ route enable <path>
+package bitcom
+
+import (
+	"fmt"
+
+	"github.com/bitcoinschema/go-bob"
+)
+
+// Prefix is the prefix of Bitcom command tapes
+const Prefix = "$"
+
+// Type is the type of a Bitcom command
+type Type string
+
+// Bitcom commands
+const (
+	TypeEcho  Type = "echo"
+	TypeSu    Type = "su"
+	TypeRoute Type = "route"
+)
+
+// Route actions
+const (
+	RouteAdd    = "add"
+	RouteEnable = "enable"
+)
+
+// Command is a decoded Bitcom command
+//
+// Content, Filename and Append are set for echo commands, Key for su
+// commands and Action, Address, Path and Endpoint for route commands
+// (route enable only sets Path).
+type Command struct {
+	Type     Type   `json:"type"`
+	Content  []byte `json:"content,omitempty"`
+	Filename string `json:"filename,omitempty"`
+	Append   bool   `json:"append,omitempty"`
+	Key      string `json:"key,omitempty"`
+	Action   string `json:"action,omitempty"`
+	Address  string `json:"address,omitempty"`
+	Path     string `json:"path,omitempty"`
+	Endpoint string `json:"endpoint,omitempty"`
+}
+
+// NewFromTape decodes a Bitcom command tape
+func NewFromTape(tape *bob.Tape) (*Command, error) {
+	if tape == nil {
+		return nil, fmt.Errorf("tape must be set")
+	}
+	prefix, err := tape.Prefix()
+	if err != nil {
+		return nil, err
+	}
+	if prefix != Prefix {
+		return nil, fmt.Errorf("tape prefix %q is not %s", prefix, Prefix)
+	}
+	if len(tape.Cell) < 2 {
+		return nil, fmt.Errorf("bitcom tape is missing the command")
+	}
+
+	// the echo content may be binary, which String keeps as is
+	args := make([]string, len(tape.Cell)-1)
+	for i := range args {
+		c, _ := tape.CellAt(i + 1)
+		if args[i], err = c.String(); err != nil {
+			return nil, fmt.Errorf("cell %d: %w", i+1, err)
+		}
+	}
+	name := args[0]
+	args = args[1:]
+
+	cmd := &Command{Type: Type(name)}
+	switch cmd.Type {
+	case TypeEcho:
+		if len(args) != 3 {
+			return nil, fmt.Errorf("echo must have 3 arguments, got %d", len(args))
+		}
+		switch args[1] {
+		case "to", ">":
+		case ">>":
+			cmd.Append = true
+		default:
+			return nil, fmt.Errorf("unknown echo operator %q", args[1])
+		}
+		if args[2] == "" {
+			return nil, fmt.Errorf("echo is missing the filename")
+		}
+		cmd.Content, cmd.Filename = []byte(args[0]), args[2]
+	case TypeSu:
+		if len(args) != 1 || args[0] == "" {
+			return nil, fmt.Errorf("su must have a key")
+		}
+		cmd.Key = args[0]
+	case TypeRoute:
+		if len(args) == 0 {
+			return nil, fmt.Errorf("route is missing the action")
+		}
+		cmd.Action = args[0]
+		switch {
+		case cmd.Action == RouteAdd && len(args) == 4:
+			cmd.Address, cmd.Path, cmd.Endpoint = args[1], args[2], args[3]
+		case cmd.Action == RouteEnable && len(args) == 2:
+			cmd.Path = args[1]
+		default:
+			return nil, fmt.Errorf("invalid route %s with %d arguments", cmd.Action, len(args)-1)
+		}
+	default:
+		return nil, fmt.Errorf("unknown command %q", name)
+	}
+	return cmd, nil
+}
+
+// Decode is the bob.TapeDecoder of Bitcom command tapes
+func Decode(ctx *bob.TapeContext) (any, error) {
+	return NewFromTape(ctx.CurrentTape())
+}
+
+// Register registers the Bitcom decoder with the registry
+func Register(reg *bob.Registry) error {
+	return reg.Register(Prefix, Decode)
+}
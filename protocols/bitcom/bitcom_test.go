@@ -0,0 +1,81 @@
+package bitcom
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/bitcoinschema/go-bob"
+	test "github.com/bitcoinschema/go-bob/testing"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNewFromTape tests the method NewFromTape()
+func TestNewFromTape(t *testing.T) {
+	t.Parallel()
+
+	var (
+		// Testing every command and invalid tapes
+		tests = []struct {
+			name          string
+			tape          *bob.Tape
+			expected      *Command
+			expectedError bool
+		}{
+			{"echo to", (*bob.Tape)(test.Tape(Prefix, "echo", "hello", "to", "README.md")), &Command{Type: TypeEcho, Content: []byte("hello"), Filename: "README.md"}, false},
+			{"echo >", (*bob.Tape)(test.Tape(Prefix, "echo", "hello", ">", "README.md")), &Command{Type: TypeEcho, Content: []byte("hello"), Filename: "README.md"}, false},
+			{"echo >>", (*bob.Tape)(test.Tape(Prefix, "echo", "hello", ">>", "README.md")), &Command{Type: TypeEcho, Content: []byte("hello"), Filename: "README.md", Append: true}, false},
+			{"su", (*bob.Tape)(test.Tape(Prefix, "su", "02abcd")), &Command{Type: TypeSu, Key: "02abcd"}, false},
+			{"route add", (*bob.Tape)(test.Tape(Prefix, "route", "add", "19HxigV4QyBv3tHpQVcUEQyq1pzZVdoAut", "/c/:hash", "https://b.bitdb.network")), &Command{
+				Type:     TypeRoute,
+				Action:   RouteAdd,
+				Address:  "19HxigV4QyBv3tHpQVcUEQyq1pzZVdoAut",
+				Path:     "/c/:hash",
+				Endpoint: "https://b.bitdb.network",
+			}, false},
+			{"route enable", (*bob.Tape)(test.Tape(Prefix, "route", "enable", "/c/:hash")), &Command{Type: TypeRoute, Action: RouteEnable, Path: "/c/:hash"}, false},
+			{"echo without filename", (*bob.Tape)(test.Tape(Prefix, "echo", "hello", "to", "")), nil, true},
+			{"echo bad operator", (*bob.Tape)(test.Tape(Prefix, "echo", "hello", "|", "README.md")), nil, true},
+			{"echo too few arguments", (*bob.Tape)(test.Tape(Prefix, "echo", "hello")), nil, true},
+			{"su without key", (*bob.Tape)(test.Tape(Prefix, "su")), nil, true},
+			{"route without action", (*bob.Tape)(test.Tape(Prefix, "route")), nil, true},
+			{"route unknown action", (*bob.Tape)(test.Tape(Prefix, "route", "delete", "/c/:hash")), nil, true},
+			{"route add too few arguments", (*bob.Tape)(test.Tape(Prefix, "route", "add", "/c/:hash")), nil, true},
+			{"unknown command", (*bob.Tape)(test.Tape(Prefix, "useradd", "1abc")), nil, true},
+			{"missing command", (*bob.Tape)(test.Tape(Prefix)), nil, true},
+			{"wrong prefix", (*bob.Tape)(test.Tape("meta", "su", "02abcd")), nil, true},
+			{"nil", nil, nil, true},
+		}
+	)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cmd, err := NewFromTape(test.tape)
+			if test.expectedError {
+				require.Error(t, err)
+				require.Nil(t, cmd)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expected, cmd)
+		})
+	}
+}
+
+// ExampleNewFromTape example using NewFromTape()
+func ExampleNewFromTape() {
+	cmd, err := NewFromTape((*bob.Tape)(test.Tape(Prefix, "echo", "# Protocol", "to", "README.md")))
+	if err != nil {
+		fmt.Printf("error occurred: %s", err.Error())
+		return
+	}
+	fmt.Printf("%s %q to %s", cmd.Type, cmd.Content, cmd.Filename)
+	// Output:echo "# Protocol" to README.md
+}
+
+// BenchmarkNewFromTape benchmarks the method NewFromTape()
+func BenchmarkNewFromTape(b *testing.B) {
+	tape := (*bob.Tape)(test.Tape(Prefix, "route", "add", "19HxigV4QyBv3tHpQVcUEQyq1pzZVdoAut", "/c/:hash", "https://b.bitdb.network"))
+	for i := 0; i < b.N; i++ {
+		_, _ = NewFromTape(tape)
+	}
+}
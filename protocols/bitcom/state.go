@@ -0,0 +1,189 @@
+package bitcom
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/bitcoinschema/go-bob"
+)
+
+// Account is the state of a Bitcom address
+//
+// Keys are in the order they were added. Routes are keyed by path.
+type Account struct {
+	Address string            `json:"address"`
+	Keys    []string          `json:"keys,omitempty"`
+	Files   map[string][]byte `json:"files,omitempty"`
+	Routes  map[string]*Route `json:"routes,omitempty"`
+}
+
+// Route is an endpoint added by a Bitcom address for the protocol of another
+// Bitcom address
+type Route struct {
+	Address  string `json:"address"`
+	Path     string `json:"path"`
+	Endpoint string `json:"endpoint"`
+	Enabled  bool   `json:"enabled"`
+}
+
+// State is the in-memory state of every Bitcom address, built by applying
+// txs in order
+type State struct {
+	mu       sync.RWMutex
+	accounts map[string]*Account
+}
+
+// NewState returns an empty state
+func NewState() *State {
+	return &State{accounts: make(map[string]*Account)}
+}
+
+// SkippedTx is a tx that was not applied, with the reason
+type SkippedTx struct {
+	Index int
+	Tx    *bob.Tx
+	Err   error
+}
+
+// Apply applies the Bitcom commands of the txs, in order
+//
+// The commands of a tx are issued by the address of its first input. A tx
+// with an invalid command, or whose first input has no address, is not
+// applied: it is skipped and returned, and the replay goes on.
+func (s *State) Apply(txs ...*bob.Tx) []SkippedTx {
+	var skipped []SkippedTx
+	for i, t := range txs {
+		if err := s.apply(t); err != nil {
+			skipped = append(skipped, SkippedTx{Index: i, Tx: t, Err: err})
+		}
+	}
+	return skipped
+}
+
+// apply applies the commands of a single tx
+func (s *State) apply(t *bob.Tx) error {
+	if t == nil {
+		return fmt.Errorf("tx must be set")
+	}
+
+	var commands []*Command
+	for i := range t.Out {
+		for j := range t.Out[i].Tape {
+			tape := (*bob.Tape)(&t.Out[i].Tape[j])
+			if prefix, err := tape.Prefix(); err != nil || prefix != Prefix {
+				continue
+			}
+			cmd, err := NewFromTape(tape)
+			if err != nil {
+				return fmt.Errorf("tx %s output %d tape %d: %w", t.Tx.Tx.H, i, j, err)
+			}
+			commands = append(commands, cmd)
+		}
+	}
+	if len(commands) == 0 {
+		return nil
+	}
+
+	sender, ok := senderAddress(t)
+	if !ok {
+		return fmt.Errorf("tx %s first input has no address", t.Tx.Tx.H)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account, ok := s.accounts[sender]
+	if !ok {
+		account = &Account{Address: sender}
+		s.accounts[sender] = account
+	}
+	for _, cmd := range commands {
+		account.apply(cmd)
+	}
+	return nil
+}
+
+// senderAddress returns the address of the first input of the tx, bpu
+// setting "false" when it is not known
+func senderAddress(t *bob.Tx) (string, bool) {
+	if len(t.In) == 0 {
+		return "", false
+	}
+	a := t.In[0].E.A
+	if a == nil || *a == "" || *a == "false" {
+		return "", false
+	}
+	return *a, true
+}
+
+// apply applies a command issued by the account
+func (a *Account) apply(cmd *Command) {
+	switch cmd.Type {
+	case TypeEcho:
+		if a.Files == nil {
+			a.Files = make(map[string][]byte)
+		}
+		if cmd.Append {
+			a.Files[cmd.Filename] = append(a.Files[cmd.Filename], cmd.Content...)
+		} else {
+			a.Files[cmd.Filename] = slices.Clone(cmd.Content)
+		}
+	case TypeSu:
+		if !slices.Contains(a.Keys, cmd.Key) {
+			a.Keys = append(a.Keys, cmd.Key)
+		}
+	case TypeRoute:
+		if a.Routes == nil {
+			a.Routes = make(map[string]*Route)
+		}
+		switch cmd.Action {
+		case RouteAdd:
+			a.Routes[cmd.Path] = &Route{Address: cmd.Address, Path: cmd.Path, Endpoint: cmd.Endpoint}
+		case RouteEnable:
+			if r, ok := a.Routes[cmd.Path]; ok {
+				r.Enabled = true
+			}
+		}
+	}
+}
+
+// Account returns a copy of the state of the address, or nil if it has not
+// issued any command
+func (s *State) Account(address string) *Account {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	a, ok := s.accounts[address]
+	if !ok {
+		return nil
+	}
+	c := &Account{Address: a.Address, Keys: slices.Clone(a.Keys)}
+	if a.Files != nil {
+		c.Files = make(map[string][]byte, len(a.Files))
+		for name, content := range a.Files {
+			c.Files[name] = slices.Clone(content)
+		}
+	}
+	if a.Routes != nil {
+		c.Routes = make(map[string]*Route, len(a.Routes))
+		for path, r := range a.Routes {
+			route := *r
+			c.Routes[path] = &route
+		}
+	}
+	return c
+}
+
+// Addresses returns the Bitcom addresses known to the state, sorted
+func (s *State) Addresses() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	addresses := make([]string, 0, len(s.accounts))
+	for address := range s.accounts {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+	return addresses
+}
@@ -0,0 +1,161 @@
+package bitcom
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/bitcoinschema/go-bob"
+	test "github.com/bitcoinschema/go-bob/testing"
+	"github.com/bitcoinschema/go-bpu"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/bsv-blockchain/go-sdk/script"
+	"github.com/bsv-blockchain/go-sdk/transaction"
+	"github.com/bsv-blockchain/go-sdk/transaction/template/p2pkh"
+	"github.com/stretchr/testify/require"
+)
+
+// testTx returns a tx signed by the key with an output for every command
+func testTx(t testing.TB, key *ec.PrivateKey, commands ...[]string) *bob.Tx {
+	tx := transaction.NewTransaction()
+	if key != nil {
+		lock, err := p2pkh.Lock(test.Address(key))
+		require.NoError(t, err)
+		unlock, err := p2pkh.Unlock(key, nil)
+		require.NoError(t, err)
+		require.NoError(t, tx.AddInputFrom(
+			"6386afa223e54d4f955e44a1ef4ae5b18bbb8689dff078627a7cb842fad4f7c6", 0, lock.String(), 1000, unlock,
+		))
+	}
+	for _, command := range commands {
+		s := script.NewFromBytes([]byte{})
+		_ = s.AppendOpcodes(script.OpFALSE, script.OpRETURN)
+		_ = s.AppendPushDataString(Prefix)
+		for _, arg := range command {
+			_ = s.AppendPushDataString(arg)
+		}
+		tx.AddOutput(&transaction.TransactionOutput{LockingScript: s})
+	}
+	if key != nil {
+		require.NoError(t, tx.Sign())
+	}
+
+	bobTx, err := bob.NewFromRawTxString(tx.String())
+	require.NoError(t, err)
+	return bobTx
+}
+
+// TestState_Apply tests replaying txs into the state
+func TestState_Apply(t *testing.T) {
+	t.Parallel()
+
+	key1, key2 := test.Key(1), test.Key(2)
+	address1, address2 := test.Address(key1).AddressString, test.Address(key2).AddressString
+
+	s := NewState()
+	require.Empty(t, s.Apply(
+		testTx(t, key1,
+			[]string{"echo", "v1", "to", "README.md"},
+			[]string{"su", "02abcd"},
+		),
+		testTx(t, key2, []string{"echo", "hello", ">", "index.html"}),
+		testTx(t, key1,
+			[]string{"echo", "v2", "to", "README.md"},
+			[]string{"echo", " more", ">>", "README.md"},
+			[]string{"su", "03ef01"},
+			[]string{"su", "02abcd"},
+			[]string{"route", "add", address2, "/c/:hash", "https://example.com"},
+			[]string{"route", "enable", "/c/:hash"},
+			[]string{"route", "enable", "/unknown"},
+		),
+		// no commands
+		testTx(t, nil),
+	))
+
+	require.Equal(t, []string{address1, address2}, s.Addresses())
+	require.Equal(t, &Account{
+		Address: address1,
+		Keys:    []string{"02abcd", "03ef01"},
+		Files:   map[string][]byte{"README.md": []byte("v2 more")},
+		Routes: map[string]*Route{
+			"/c/:hash": {Address: address2, Path: "/c/:hash", Endpoint: "https://example.com", Enabled: true},
+		},
+	}, s.Account(address1))
+	require.Equal(t, &Account{
+		Address: address2,
+		Files:   map[string][]byte{"index.html": []byte("hello")},
+	}, s.Account(address2))
+	require.Nil(t, s.Account("1unknown"))
+
+	// accounts are copies
+	s.Account(address1).Files["README.md"][0] = 'x'
+	require.Equal(t, []byte("v2 more"), s.Account(address1).Files["README.md"])
+}
+
+// TestState_Apply_Skipped tests txs that cannot be applied
+func TestState_Apply_Skipped(t *testing.T) {
+	t.Parallel()
+
+	key1, key2 := test.Key(1), test.Key(2)
+	address1, address2 := test.Address(key1).AddressString, test.Address(key2).AddressString
+
+	// the first input has no address, the second one does
+	noSender := testTx(t, key2, []string{"su", "02abcd"})
+	noSender.In = append([]bpu.Input{{}}, noSender.In...)
+
+	invalid := testTx(t, key1,
+		[]string{"su", "02abcd"},
+		[]string{"echo", "hello"},
+	)
+
+	s := NewState()
+	skipped := s.Apply(
+		invalid,
+		nil,
+		noSender,
+		testTx(t, nil, []string{"su", "02abcd"}),
+		testTx(t, key1, []string{"su", "03ef01"}),
+	)
+	require.Len(t, skipped, 4)
+	require.Equal(t, 0, skipped[0].Index)
+	require.Same(t, invalid, skipped[0].Tx)
+	require.ErrorContains(t, skipped[0].Err, "output 1 tape 1")
+	require.Equal(t, 1, skipped[1].Index)
+	require.Equal(t, 2, skipped[2].Index)
+	require.ErrorContains(t, skipped[2].Err, "first input has no address")
+	require.Equal(t, 3, skipped[3].Index)
+
+	// the skipped txs are not applied, the next ones are
+	require.Equal(t, []string{address1}, s.Addresses())
+	require.Equal(t, []string{"03ef01"}, s.Account(address1).Keys)
+	require.Nil(t, s.Account(address2))
+}
+
+// ExampleState_Apply example using Apply()
+func ExampleState_Apply() {
+	sender := "1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMH"
+	bobTx := &bob.Tx{}
+	bobTx.In = []bpu.Input{{XPut: bpu.XPut{E: bpu.E{A: &sender}}}}
+	bobTx.Out = []bpu.Output{{XPut: bpu.XPut{Tape: []bpu.Tape{
+		*test.Tape(Prefix, "echo", "# Protocol", "to", "README.md"),
+	}}}}
+
+	s := NewState()
+	for _, skipped := range s.Apply(bobTx) {
+		fmt.Printf("error occurred: %s", skipped.Err.Error())
+		return
+	}
+	for _, address := range s.Addresses() {
+		fmt.Printf("%s: %s", address, s.Account(address).Files["README.md"])
+	}
+	// Output:1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMH: # Protocol
+}
+
+// BenchmarkState_Apply benchmarks the method Apply()
+func BenchmarkState_Apply(b *testing.B) {
+	key := test.Key(1)
+	bobTx := testTx(b, key, []string{"echo", "hello", "to", "README.md"}, []string{"su", "02abcd"})
+	s := NewState()
+	for i := 0; i < b.N; i++ {
+		_ = s.Apply(bobTx)
+	}
+}
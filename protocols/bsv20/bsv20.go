@@ -0,0 +1,299 @@
+// Package bsv20 decodes BSV-20 and BSV-21 fungible token operations
+// inscribed in 1Sat Ordinals envelopes
+//
+// Specs: https://docs.1satordinals.com/fungible-tokens/bsv-20
+//
+// An operation is a JSON object inscribed with the application/bsv-20
+// content type. Every value is a string:
+//
+//	{"p":"bsv-20","op":"deploy","tick":"ORDI","max":"21000000","lim":"1000","dec":"8"}
+//	{"p":"bsv-20","op":"mint","tick":"ORDI","amt":"1000"}
+//	{"p":"bsv-20","op":"transfer","tick":"ORDI","amt":"100"}
+//	{"p":"bsv-20","op":"deploy+mint","sym":"ORDI","amt":"21000000","dec":"8"}
+//	{"p":"bsv-20","op":"transfer","id":"<txid>_<vout>","amt":"100"}
+package bsv20
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/bitcoinschema/go-bob"
+	"github.com/bitcoinschema/go-bob/protocols/ord"
+)
+
+// ContentType is the inscription content type of token operations
+const ContentType = "application/bsv-20"
+
+// Protocol is the value of the "p" field
+const Protocol = "bsv-20"
+
+// MaxDecimals is the highest allowed decimal precision
+const MaxDecimals = 18
+
+// maxAmount is the highest allowed amount (max uint64)
+var maxAmount = new(big.Int).SetUint64(^uint64(0))
+
+// Op is a token operation
+type Op string
+
+// Token operations, deploy+mint being a BSV-21 deploy
+const (
+	OpDeploy     Op = "deploy"
+	OpMint       Op = "mint"
+	OpTransfer   Op = "transfer"
+	OpDeployMint Op = "deploy+mint"
+)
+
+// Token is a decoded token operation
+//
+// Tick is set for BSV-20 operations and ID for BSV-21 operations, ID being
+// the outpoint of the deploy+mint inscription (<txid>_<vout>). Amounts are
+// integers in the smallest unit of the token, Dec being the display precision.
+type Token struct {
+	Op     Op       `json:"op"`
+	Tick   string   `json:"tick,omitempty"`
+	ID     string   `json:"id,omitempty"`
+	Amt    *big.Int `json:"amt,omitempty"`
+	Max    *big.Int `json:"max,omitempty"`
+	Lim    *big.Int `json:"lim,omitempty"`
+	Dec    uint8    `json:"dec"`
+	Sym    string   `json:"sym,omitempty"`
+	Icon   string   `json:"icon,omitempty"`
+	Output uint32   `json:"output"`
+	Owner  string   `json:"owner,omitempty"`
+}
+
+// operation is the JSON form of a token operation
+type operation struct {
+	P    string  `json:"p"`
+	Op   string  `json:"op"`
+	Tick *string `json:"tick"`
+	ID   *string `json:"id"`
+	Amt  *string `json:"amt"`
+	Max  *string `json:"max"`
+	Lim  *string `json:"lim"`
+	Dec  *string `json:"dec"`
+	Sym  *string `json:"sym"`
+	Icon *string `json:"icon"`
+}
+
+// IsToken returns true if the inscription has the token content type
+func IsToken(inscription *ord.Inscription) bool {
+	if inscription == nil {
+		return false
+	}
+	contentType, _, _ := strings.Cut(inscription.ContentType, ";")
+	return strings.EqualFold(strings.TrimSpace(contentType), ContentType)
+}
+
+// DecodedToken is the result of decoding the token inscription of a single
+// output, either Token or Err is set
+type DecodedToken struct {
+	Output uint32
+	Token  *Token
+	Err    error
+}
+
+// DecodedTokens are the results of decoding the outputs of a tx, in output order
+type DecodedTokens []DecodedToken
+
+// Errors returns the errors of the outputs whose token operation failed to decode
+func (d DecodedTokens) Errors() []error {
+	var errs []error
+	for i := range d {
+		if d[i].Err != nil {
+			errs = append(errs, d[i].Err)
+		}
+	}
+	return errs
+}
+
+// Tokens returns the successfully decoded token operations, keyed by output index
+func (d DecodedTokens) Tokens() map[uint32]*Token {
+	tokens := make(map[uint32]*Token)
+	for i := range d {
+		if d[i].Err == nil {
+			tokens[d[i].Output] = d[i].Token
+		}
+	}
+	return tokens
+}
+
+// NewFromTx decodes the token operations of every output of the tx
+//
+// Outputs without a token inscription are left out, as are outputs whose
+// envelope failed to decode (see ord.NewFromTx). Outputs whose token
+// operation failed to decode are included with Err set, so one malformed
+// token does not hide the others. The ID of deploy+mint operations is set
+// from the txid and output index.
+func NewFromTx(t *bob.Tx) (DecodedTokens, error) {
+	inscriptions, err := ord.NewFromTx(t)
+	if err != nil {
+		return nil, err
+	}
+
+	var results DecodedTokens
+	for _, d := range inscriptions {
+		if d.Err != nil || !IsToken(d.Inscription) {
+			continue
+		}
+		token, err := NewFromInscription(d.Inscription)
+		if err != nil {
+			results = append(results, DecodedToken{Output: d.Output, Err: fmt.Errorf("output %d: %w", d.Output, err)})
+			continue
+		}
+		if token.Op == OpDeployMint {
+			token.ID = fmt.Sprintf("%s_%d", t.Tx.Tx.H, d.Output)
+		}
+		results = append(results, DecodedToken{Output: d.Output, Token: token})
+	}
+	return results, nil
+}
+
+// NewFromInscription decodes the token operation of an inscription
+func NewFromInscription(inscription *ord.Inscription) (*Token, error) {
+	if !IsToken(inscription) {
+		return nil, fmt.Errorf("inscription is not %s", ContentType)
+	}
+	token, err := NewFromJSON(inscription.Content)
+	if err != nil {
+		return nil, err
+	}
+	token.Output = inscription.Output
+	token.Owner = inscription.Owner
+	return token, nil
+}
+
+// NewFromJSON decodes and validates a token operation
+func NewFromJSON(data []byte) (*Token, error) {
+	var o operation
+	if err := json.Unmarshal(data, &o); err != nil {
+		return nil, fmt.Errorf("invalid token json: %w", err)
+	}
+	if o.P != Protocol {
+		return nil, fmt.Errorf("protocol %q is not %s", o.P, Protocol)
+	}
+
+	token := &Token{
+		Op:   Op(strings.ToLower(o.Op)),
+		Tick: value(o.Tick),
+		ID:   value(o.ID),
+		Sym:  value(o.Sym),
+		Icon: value(o.Icon),
+	}
+
+	var err error
+	if token.Amt, err = parseAmount("amt", o.Amt); err != nil {
+		return nil, err
+	}
+	if token.Max, err = parseAmount("max", o.Max); err != nil {
+		return nil, err
+	}
+	if token.Lim, err = parseAmount("lim", o.Lim); err != nil {
+		return nil, err
+	}
+	if token.Dec, err = parseDecimals(o.Dec); err != nil {
+		return nil, err
+	}
+
+	switch token.Op {
+	case OpDeploy:
+		if token.Tick == "" {
+			return nil, fmt.Errorf("deploy is missing tick")
+		}
+		if token.Max == nil {
+			return nil, fmt.Errorf("deploy is missing max")
+		}
+	case OpMint:
+		if token.Tick == "" {
+			return nil, fmt.Errorf("mint is missing tick")
+		}
+		if token.Amt == nil {
+			return nil, fmt.Errorf("mint is missing amt")
+		}
+	case OpTransfer:
+		if (token.Tick == "") == (token.ID == "") {
+			return nil, fmt.Errorf("transfer must have either tick or id")
+		}
+		if token.ID != "" {
+			if err = validateID(token.ID); err != nil {
+				return nil, err
+			}
+		}
+		if token.Amt == nil {
+			return nil, fmt.Errorf("transfer is missing amt")
+		}
+	case OpDeployMint:
+		if token.Amt == nil {
+			return nil, fmt.Errorf("deploy+mint is missing amt")
+		}
+		token.ID = ""
+	default:
+		return nil, fmt.Errorf("unknown op %q", o.Op)
+	}
+	return token, nil
+}
+
+// value returns the string, or an empty string if it is not set
+func value(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// parseAmount parses an amount field, which must be a positive integer
+// string of digits that fits in a uint64
+func parseAmount(field string, s *string) (*big.Int, error) {
+	if s == nil {
+		return nil, nil
+	}
+	if *s == "" || strings.Trim(*s, "0123456789") != "" {
+		return nil, fmt.Errorf("%s %q is not an integer", field, *s)
+	}
+	amount, ok := new(big.Int).SetString(*s, 10)
+	if !ok {
+		return nil, fmt.Errorf("%s %q is not an integer", field, *s)
+	}
+	if amount.Sign() == 0 {
+		return nil, fmt.Errorf("%s must be greater than 0", field)
+	}
+	if amount.Cmp(maxAmount) > 0 {
+		return nil, fmt.Errorf("%s %s is greater than %s", field, amount, maxAmount)
+	}
+	return amount, nil
+}
+
+// parseDecimals parses the dec field, which defaults to 0
+func parseDecimals(s *string) (uint8, error) {
+	if s == nil {
+		return 0, nil
+	}
+	if *s == "" || strings.Trim(*s, "0123456789") != "" {
+		return 0, fmt.Errorf("dec %q is not an integer", *s)
+	}
+	dec, err := strconv.ParseUint(*s, 10, 8)
+	if err != nil || dec > MaxDecimals {
+		return 0, fmt.Errorf("dec %q must be between 0 and %d", *s, MaxDecimals)
+	}
+	return uint8(dec), nil
+}
+
+// validateID checks a BSV-21 token id is an outpoint (<txid>_<vout>)
+func validateID(id string) error {
+	txID, vout, ok := strings.Cut(id, "_")
+	if !ok {
+		return fmt.Errorf("id %q is not <txid>_<vout>", id)
+	}
+	if b, err := hex.DecodeString(txID); err != nil || len(b) != 32 {
+		return fmt.Errorf("id %q has an invalid txid", id)
+	}
+	if _, err := strconv.ParseUint(vout, 10, 32); err != nil {
+		return fmt.Errorf("id %q has an invalid vout", id)
+	}
+	return nil
+}
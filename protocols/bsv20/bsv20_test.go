@@ -0,0 +1,210 @@
+package bsv20
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/bitcoinschema/go-bob"
+	"github.com/bitcoinschema/go-bob/protocols/ord"
+	test "github.com/bitcoinschema/go-bob/testing"
+	"github.com/bsv-blockchain/go-sdk/script"
+	"github.com/bsv-blockchain/go-sdk/transaction"
+	"github.com/bsv-blockchain/go-sdk/transaction/template/p2pkh"
+	"github.com/stretchr/testify/require"
+)
+
+const testID = "6386afa223e54d4f955e44a1ef4ae5b18bbb8689dff078627a7cb842fad4f7c6_0"
+
+// testOutput returns an output script inscribing the content in front of a P2PKH script
+func testOutput(t testing.TB, contentType, content string) (*script.Script, string) {
+	address := test.Address(test.Key(1))
+	lock, err := p2pkh.Lock(address)
+	require.NoError(t, err)
+
+	s := script.NewFromBytes([]byte{})
+	test.AppendInscription(s, contentType, []byte(content))
+	*s = append(*s, *lock...)
+	return s, address.AddressString
+}
+
+// testTx returns a bob tx with an output for every content
+func testTx(t testing.TB, contentType string, contents ...string) (*bob.Tx, string) {
+	tx := transaction.NewTransaction()
+	var owner string
+	for _, content := range contents {
+		var s *script.Script
+		s, owner = testOutput(t, contentType, content)
+		tx.AddOutput(&transaction.TransactionOutput{LockingScript: s, Satoshis: 1})
+	}
+	bobTx, err := bob.NewFromRawTxString(tx.String())
+	require.NoError(t, err)
+	return bobTx, owner
+}
+
+// TestNewFromJSON tests decoding and validating every operation
+func TestNewFromJSON(t *testing.T) {
+	t.Parallel()
+
+	var (
+		// Testing valid and malformed operations
+		tests = []struct {
+			name          string
+			json          string
+			expected      *Token
+			expectedError bool
+		}{
+			{
+				"deploy",
+				`{"p":"bsv-20","op":"deploy","tick":"ORDI","max":"21000000","lim":"1000","dec":"8"}`,
+				&Token{Op: OpDeploy, Tick: "ORDI", Max: big.NewInt(21000000), Lim: big.NewInt(1000), Dec: 8},
+				false,
+			},
+			{
+				"mint",
+				`{"p":"bsv-20","op":"mint","tick":"ORDI","amt":"1000"}`,
+				&Token{Op: OpMint, Tick: "ORDI", Amt: big.NewInt(1000)},
+				false,
+			},
+			{
+				"transfer by tick",
+				`{"p":"bsv-20","op":"transfer","tick":"ORDI","amt":"18446744073709551615"}`,
+				&Token{Op: OpTransfer, Tick: "ORDI", Amt: new(big.Int).SetUint64(18446744073709551615)},
+				false,
+			},
+			{
+				"transfer by id",
+				`{"p":"bsv-20","op":"transfer","id":"` + testID + `","amt":"100"}`,
+				&Token{Op: OpTransfer, ID: testID, Amt: big.NewInt(100)},
+				false,
+			},
+			{
+				"deploy+mint",
+				`{"p":"bsv-20","op":"deploy+mint","sym":"ORDI","icon":"` + testID + `","amt":"21000000","dec":"18"}`,
+				&Token{Op: OpDeployMint, Sym: "ORDI", Icon: testID, Amt: big.NewInt(21000000), Dec: 18},
+				false,
+			},
+			{"invalid json", `{"p":"bsv-20"`, nil, true},
+			{"wrong protocol", `{"p":"brc-20","op":"mint","tick":"ORDI","amt":"1"}`, nil, true},
+			{"unknown op", `{"p":"bsv-20","op":"burn","tick":"ORDI","amt":"1"}`, nil, true},
+			{"numeric amount", `{"p":"bsv-20","op":"mint","tick":"ORDI","amt":1000}`, nil, true},
+			{"decimal amount", `{"p":"bsv-20","op":"mint","tick":"ORDI","amt":"1.5"}`, nil, true},
+			{"negative amount", `{"p":"bsv-20","op":"mint","tick":"ORDI","amt":"-1"}`, nil, true},
+			{"signed amount", `{"p":"bsv-20","op":"mint","tick":"ORDI","amt":"+1"}`, nil, true},
+			{"zero amount", `{"p":"bsv-20","op":"mint","tick":"ORDI","amt":"0"}`, nil, true},
+			{"empty amount", `{"p":"bsv-20","op":"mint","tick":"ORDI","amt":""}`, nil, true},
+			{"amount overflow", `{"p":"bsv-20","op":"mint","tick":"ORDI","amt":"18446744073709551616"}`, nil, true},
+			{"zero max", `{"p":"bsv-20","op":"deploy","tick":"ORDI","max":"0"}`, nil, true},
+			{"bad lim", `{"p":"bsv-20","op":"deploy","tick":"ORDI","max":"10","lim":"1e3"}`, nil, true},
+			{"dec too high", `{"p":"bsv-20","op":"deploy","tick":"ORDI","max":"10","dec":"19"}`, nil, true},
+			{"negative dec", `{"p":"bsv-20","op":"deploy","tick":"ORDI","max":"10","dec":"-1"}`, nil, true},
+			{"deploy without max", `{"p":"bsv-20","op":"deploy","tick":"ORDI"}`, nil, true},
+			{"deploy without tick", `{"p":"bsv-20","op":"deploy","max":"10"}`, nil, true},
+			{"mint without amount", `{"p":"bsv-20","op":"mint","tick":"ORDI"}`, nil, true},
+			{"transfer with tick and id", `{"p":"bsv-20","op":"transfer","tick":"ORDI","id":"` + testID + `","amt":"1"}`, nil, true},
+			{"transfer with bad id", `{"p":"bsv-20","op":"transfer","id":"abcd_0","amt":"1"}`, nil, true},
+			{"transfer with bad vout", `{"p":"bsv-20","op":"transfer","id":"` + testID + `x","amt":"1"}`, nil, true},
+			{"deploy+mint without amount", `{"p":"bsv-20","op":"deploy+mint","sym":"ORDI"}`, nil, true},
+		}
+	)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			token, err := NewFromJSON([]byte(test.json))
+			if test.expectedError {
+				require.Error(t, err)
+				require.Nil(t, token)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expected, token)
+		})
+	}
+}
+
+// TestNewFromTx tests decoding the token operations of a tx
+func TestNewFromTx(t *testing.T) {
+	t.Parallel()
+
+	t.Run("deploy+mint", func(t *testing.T) {
+		bobTx, owner := testTx(t, ContentType,
+			`{"p":"bsv-20","op":"deploy+mint","sym":"ORDI","amt":"100"}`,
+			`{"p":"bsv-20","op":"transfer","tick":"ORDI","amt":"5"}`,
+		)
+
+		decoded, err := NewFromTx(bobTx)
+		require.NoError(t, err)
+		tokens := decoded.Tokens()
+		require.Len(t, tokens, 2)
+		require.Equal(t, bobTx.Tx.Tx.H+"_0", tokens[0].ID)
+		require.Equal(t, owner, tokens[0].Owner)
+		require.Equal(t, uint32(1), tokens[1].Output)
+		require.Equal(t, big.NewInt(5), tokens[1].Amt)
+	})
+
+	t.Run("other content", func(t *testing.T) {
+		bobTx, _ := testTx(t, "text/plain", `{"p":"bsv-20","op":"mint","tick":"ORDI","amt":"1"}`)
+		tokens, err := NewFromTx(bobTx)
+		require.NoError(t, err)
+		require.Empty(t, tokens)
+	})
+
+	t.Run("content type parameters", func(t *testing.T) {
+		bobTx, _ := testTx(t, "application/bsv-20; charset=utf-8", `{"p":"bsv-20","op":"mint","tick":"ORDI","amt":"1"}`)
+		tokens, err := NewFromTx(bobTx)
+		require.NoError(t, err)
+		require.Len(t, tokens, 1)
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		bobTx, _ := testTx(t, ContentType,
+			`{"p":"bsv-20","op":"mint","tick":"ORDI","amt":"0.1"}`,
+			`{"p":"bsv-20","op":"mint","tick":"ORDI","amt":"1"}`,
+		)
+		decoded, err := NewFromTx(bobTx)
+		require.NoError(t, err)
+		require.Len(t, decoded, 2)
+		require.Nil(t, decoded[0].Token)
+		require.ErrorContains(t, decoded[0].Err, "output 0: ")
+		require.Equal(t, []error{decoded[0].Err}, decoded.Errors())
+
+		// the malformed token does not hide the other one
+		require.Equal(t, uint32(1), decoded[1].Output)
+		require.Equal(t, big.NewInt(1), decoded[1].Token.Amt)
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		_, err := NewFromTx(nil)
+		require.Error(t, err)
+	})
+}
+
+// TestNewFromInscription tests decoding an inscription with another content type
+func TestNewFromInscription(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewFromInscription(&ord.Inscription{ContentType: "text/plain"})
+	require.Error(t, err)
+
+	_, err = NewFromInscription(nil)
+	require.Error(t, err)
+}
+
+// ExampleNewFromJSON example using NewFromJSON()
+func ExampleNewFromJSON() {
+	token, err := NewFromJSON([]byte(`{"p":"bsv-20","op":"mint","tick":"ORDI","amt":"1000"}`))
+	if err != nil {
+		fmt.Printf("error occurred: %s", err.Error())
+		return
+	}
+	fmt.Printf("%s %s %s", token.Op, token.Amt, token.Tick)
+	// Output:mint 1000 ORDI
+}
+
+// BenchmarkNewFromTx benchmarks the method NewFromTx()
+func BenchmarkNewFromTx(b *testing.B) {
+	bobTx, _ := testTx(b, ContentType, `{"p":"bsv-20","op":"transfer","tick":"ORDI","amt":"100"}`)
+	for i := 0; i < b.N; i++ {
+		_, _ = NewFromTx(bobTx)
+	}
+}
@@ -0,0 +1,228 @@
+// Package mapp decodes MAP (Magic Attribute Protocol) tapes
+//
+// Specs: https://map.sv/
+//
+// A MAP tape is made of the prefix, a command and its arguments:
+//
+//	1PuQa7K62MiKCtssSLKy1kh56WWU7MtUR5 SET <key> <value> [<key> <value>...]
+//	1PuQa7K62MiKCtssSLKy1kh56WWU7MtUR5 ADD <key> <value> [<value>...]
+//	1PuQa7K62MiKCtssSLKy1kh56WWU7MtUR5 DELETE <key> <value> [<value>...]
+//	1PuQa7K62MiKCtssSLKy1kh56WWU7MtUR5 REMOVE <key> [<key>...]
+//	1PuQa7K62MiKCtssSLKy1kh56WWU7MtUR5 SELECT <txid> <command> [<arguments>...]
+//
+// The package is named mapp since map is a keyword.
+package mapp
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/bitcoinschema/go-bob"
+)
+
+// Prefix is the Bitcom address of the MAP protocol
+const Prefix = "1PuQa7K62MiKCtssSLKy1kh56WWU7MtUR5"
+
+// MAP commands
+const (
+	CmdSet    = "SET"
+	CmdAdd    = "ADD"
+	CmdDelete = "DELETE"
+	CmdRemove = "REMOVE"
+	CmdSelect = "SELECT"
+)
+
+// MAP is the result of one or more MAP commands
+//
+// SET keys end up in Set, ADD values in Add, DELETE values in Delete and
+// REMOVE keys in Remove. Commands in a SELECT apply to another tx and
+// are kept apart in Select.
+type MAP struct {
+	Set    map[string]string   `json:"set,omitempty"`
+	Add    map[string][]string `json:"add,omitempty"`
+	Delete map[string][]string `json:"delete,omitempty"`
+	Remove []string            `json:"remove,omitempty"`
+	Select []*Select           `json:"select,omitempty"`
+}
+
+// Select holds the commands applied to the MAP data of another tx
+type Select struct {
+	TxID string `json:"txid"`
+	MAP  *MAP   `json:"map"`
+}
+
+// New creates a new empty MAP
+func New() *MAP {
+	return &MAP{
+		Set:    make(map[string]string),
+		Add:    make(map[string][]string),
+		Delete: make(map[string][]string),
+	}
+}
+
+// NewFromTape decodes a single MAP tape
+func NewFromTape(tape *bob.Tape) (*MAP, error) {
+	m := New()
+	if err := m.applyTape(tape); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// NewFromTapes decodes the MAP tapes among the tapes of an output and
+// merges them in order, tapes of other protocols are skipped
+func NewFromTapes(tapes []*bob.Tape) (*MAP, error) {
+	m := New()
+	for i, tape := range tapes {
+		if prefix, err := tape.Prefix(); err != nil || prefix != Prefix {
+			continue
+		}
+		if err := m.applyTape(tape); err != nil {
+			return nil, fmt.Errorf("tape %d: %w", i, err)
+		}
+	}
+	return m, nil
+}
+
+// Merge applies the commands of the other MAPs in order, as if they were
+// part of the same output
+func (m *MAP) Merge(others ...*MAP) {
+	for _, o := range others {
+		// removals and deletions apply to what came before the
+		// keys and values set by the same MAP
+		for _, key := range o.Remove {
+			m.remove(key)
+		}
+		for key, values := range o.Delete {
+			m.delete(key, values)
+		}
+		for key, value := range o.Set {
+			m.Set[key] = value
+		}
+		for key, values := range o.Add {
+			m.add(key, values)
+		}
+		m.Select = append(m.Select, o.Select...)
+	}
+}
+
+// applyTape applies the command of the tape
+func (m *MAP) applyTape(tape *bob.Tape) error {
+	if tape == nil {
+		return fmt.Errorf("tape must be set")
+	}
+	prefix, err := tape.Prefix()
+	if err != nil {
+		return err
+	}
+	if prefix != Prefix {
+		return fmt.Errorf("tape prefix %q is not %s", prefix, Prefix)
+	}
+
+	args := make([]string, 0, len(tape.Cell)-1)
+	for i := 1; i < len(tape.Cell); i++ {
+		var c *bob.Cell
+		if c, err = tape.CellAt(i); err != nil {
+			return err
+		}
+		var s string
+		if s, err = c.String(); err != nil {
+			return fmt.Errorf("cell %d: %w", i, err)
+		}
+		args = append(args, s)
+	}
+	return m.apply(args)
+}
+
+// apply applies a command with its arguments
+func (m *MAP) apply(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("command must be set")
+	}
+	cmd, args := strings.ToUpper(args[0]), args[1:]
+
+	switch cmd {
+	case CmdSet:
+		if len(args) == 0 || len(args)%2 != 0 {
+			return fmt.Errorf("%s needs key value pairs, got %d arguments", cmd, len(args))
+		}
+		for i := 0; i < len(args); i += 2 {
+			m.Set[args[i]] = args[i+1]
+		}
+	case CmdAdd, CmdDelete:
+		if len(args) < 2 {
+			return fmt.Errorf("%s needs a key and at least one value", cmd)
+		}
+		if cmd == CmdAdd {
+			m.add(args[0], args[1:])
+		} else {
+			m.delete(args[0], args[1:])
+		}
+	case CmdRemove:
+		if len(args) == 0 {
+			return fmt.Errorf("%s needs at least one key", cmd)
+		}
+		for _, key := range args {
+			m.remove(key)
+		}
+	case CmdSelect:
+		if len(args) < 2 {
+			return fmt.Errorf("%s needs a txid and a command", cmd)
+		}
+		if strings.ToUpper(args[1]) == CmdSelect {
+			return fmt.Errorf("%s can not be nested", cmd)
+		}
+		selected := New()
+		if err := selected.apply(args[1:]); err != nil {
+			return fmt.Errorf("%s %s: %w", cmd, args[0], err)
+		}
+		m.Select = append(m.Select, &Select{TxID: args[0], MAP: selected})
+	default:
+		return fmt.Errorf("unknown command %q", cmd)
+	}
+	return nil
+}
+
+// add appends the values to the list of the key
+func (m *MAP) add(key string, values []string) {
+	m.Add[key] = append(m.Add[key], values...)
+}
+
+// delete removes the values from the list of the key, values not added
+// by this MAP are recorded to be deleted from earlier ones
+func (m *MAP) delete(key string, values []string) {
+	for _, value := range values {
+		if i := slices.Index(m.Add[key], value); i >= 0 {
+			if added := slices.Delete(m.Add[key], i, i+1); len(added) == 0 {
+				delete(m.Add, key)
+			} else {
+				m.Add[key] = added
+			}
+			continue
+		}
+		m.Delete[key] = append(m.Delete[key], value)
+	}
+}
+
+// remove removes the key from the SET keys and ADD lists, and records
+// it to be removed from earlier MAPs
+func (m *MAP) remove(key string) {
+	delete(m.Set, key)
+	delete(m.Add, key)
+	if !slices.Contains(m.Remove, key) {
+		m.Remove = append(m.Remove, key)
+	}
+}
+
+// Decode is the bob.TapeDecoder of MAP tapes, decoding only the current tape
+//
+// Use NewFromTapes to merge all MAP tapes of an output.
+func Decode(ctx *bob.TapeContext) (any, error) {
+	return NewFromTape(ctx.CurrentTape())
+}
+
+// Register registers the MAP decoder with the registry
+func Register(reg *bob.Registry) error {
+	return reg.Register(Prefix, Decode)
+}
@@ -0,0 +1,191 @@
+package mapp
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/bitcoinschema/go-bob"
+	test "github.com/bitcoinschema/go-bob/testing"
+	"github.com/stretchr/testify/require"
+)
+
+var rawBobTx = test.GetTestHex("../../testing/tx/2.hex")
+
+// TestNewFromTape tests the method NewFromTape()
+func TestNewFromTape(t *testing.T) {
+	t.Parallel()
+
+	var (
+		// Testing every command and invalid tapes
+		tests = []struct {
+			name          string
+			tape          *bob.Tape
+			expected      *MAP
+			expectedError bool
+		}{
+			{
+				"set",
+				(*bob.Tape)(test.Tape(Prefix, "SET", "app", "twetch", "type", "post")),
+				&MAP{Set: map[string]string{"app": "twetch", "type": "post"}, Add: map[string][]string{}, Delete: map[string][]string{}},
+				false,
+			},
+			{
+				"add",
+				(*bob.Tape)(test.Tape(Prefix, "ADD", "tags", "a", "b")),
+				&MAP{Set: map[string]string{}, Add: map[string][]string{"tags": {"a", "b"}}, Delete: map[string][]string{}},
+				false,
+			},
+			{
+				"delete",
+				(*bob.Tape)(test.Tape(Prefix, "DELETE", "tags", "a")),
+				&MAP{Set: map[string]string{}, Add: map[string][]string{}, Delete: map[string][]string{"tags": {"a"}}},
+				false,
+			},
+			{
+				"remove",
+				(*bob.Tape)(test.Tape(Prefix, "REMOVE", "app", "type")),
+				&MAP{Set: map[string]string{}, Add: map[string][]string{}, Delete: map[string][]string{}, Remove: []string{"app", "type"}},
+				false,
+			},
+			{
+				"select",
+				(*bob.Tape)(test.Tape(Prefix, "SELECT", "abc", "SET", "app", "twetch")),
+				&MAP{Set: map[string]string{}, Add: map[string][]string{}, Delete: map[string][]string{}, Select: []*Select{{
+					TxID: "abc",
+					MAP:  &MAP{Set: map[string]string{"app": "twetch"}, Add: map[string][]string{}, Delete: map[string][]string{}},
+				}}},
+				false,
+			},
+			{
+				"lowercase command",
+				(*bob.Tape)(test.Tape(Prefix, "set", "app", "twetch")),
+				&MAP{Set: map[string]string{"app": "twetch"}, Add: map[string][]string{}, Delete: map[string][]string{}},
+				false,
+			},
+			{"set without value", (*bob.Tape)(test.Tape(Prefix, "SET", "app")), nil, true},
+			{"set without pairs", (*bob.Tape)(test.Tape(Prefix, "SET")), nil, true},
+			{"add without value", (*bob.Tape)(test.Tape(Prefix, "ADD", "tags")), nil, true},
+			{"delete without value", (*bob.Tape)(test.Tape(Prefix, "DELETE", "tags")), nil, true},
+			{"remove without key", (*bob.Tape)(test.Tape(Prefix, "REMOVE")), nil, true},
+			{"select without command", (*bob.Tape)(test.Tape(Prefix, "SELECT", "abc")), nil, true},
+			{"select with bad command", (*bob.Tape)(test.Tape(Prefix, "SELECT", "abc", "SET", "app")), nil, true},
+			{"nested select", (*bob.Tape)(test.Tape(Prefix, "SELECT", "abc", "SELECT", "def", "SET", "a", "b")), nil, true},
+			{"unknown command", (*bob.Tape)(test.Tape(Prefix, "UPSERT", "app", "twetch")), nil, true},
+			{"no command", (*bob.Tape)(test.Tape(Prefix)), nil, true},
+			{"wrong prefix", (*bob.Tape)(test.Tape("19HxigV4QyBv3tHpQVcUEQyq1pzZVdoAut", "SET", "a", "b")), nil, true},
+			{"nil", nil, nil, true},
+		}
+	)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m, err := NewFromTape(test.tape)
+			if test.expectedError {
+				require.Error(t, err)
+				require.Nil(t, m)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expected, m)
+		})
+	}
+}
+
+// TestNewFromTapes tests merging several MAP tapes of an output in order
+func TestNewFromTapes(t *testing.T) {
+	t.Parallel()
+
+	m, err := NewFromTapes([]*bob.Tape{
+		(*bob.Tape)(test.Tape(Prefix, "SET", "app", "twetch", "type", "post")),
+		(*bob.Tape)(test.Tape("19HxigV4QyBv3tHpQVcUEQyq1pzZVdoAut", "hello", "text/plain", "utf-8")),
+		(*bob.Tape)(test.Tape(Prefix, "ADD", "tags", "a", "b", "c")),
+		(*bob.Tape)(test.Tape(Prefix, "DELETE", "tags", "b", "z")),
+		(*bob.Tape)(test.Tape(Prefix, "SET", "type", "reply")),
+		(*bob.Tape)(test.Tape(Prefix, "REMOVE", "app")),
+	})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"type": "reply"}, m.Set)
+	require.Equal(t, map[string][]string{"tags": {"a", "c"}}, m.Add)
+	require.Equal(t, map[string][]string{"tags": {"z"}}, m.Delete)
+	require.Equal(t, []string{"app"}, m.Remove)
+
+	// an invalid MAP tape fails the output
+	_, err = NewFromTapes([]*bob.Tape{(*bob.Tape)(test.Tape(Prefix, "SET", "a", "b")), (*bob.Tape)(test.Tape(Prefix, "SET"))})
+	require.ErrorContains(t, err, "tape 1")
+}
+
+// TestMAP_Merge tests the method Merge()
+func TestMAP_Merge(t *testing.T) {
+	t.Parallel()
+
+	first, err := NewFromTapes([]*bob.Tape{
+		(*bob.Tape)(test.Tape(Prefix, "SET", "app", "twetch")),
+		(*bob.Tape)(test.Tape(Prefix, "ADD", "tags", "a", "b")),
+	})
+	require.NoError(t, err)
+
+	second, err := NewFromTapes([]*bob.Tape{
+		(*bob.Tape)(test.Tape(Prefix, "REMOVE", "app")),
+		(*bob.Tape)(test.Tape(Prefix, "SET", "app", "bitchat")),
+		(*bob.Tape)(test.Tape(Prefix, "DELETE", "tags", "a")),
+		(*bob.Tape)(test.Tape(Prefix, "SELECT", "abc", "ADD", "tags", "x")),
+	})
+	require.NoError(t, err)
+
+	first.Merge(second)
+	require.Equal(t, map[string]string{"app": "bitchat"}, first.Set)
+	require.Equal(t, map[string][]string{"tags": {"b"}}, first.Add)
+	require.Empty(t, first.Delete)
+	require.Equal(t, []string{"app"}, first.Remove)
+	require.Len(t, first.Select, 1)
+	require.Equal(t, "abc", first.Select[0].TxID)
+}
+
+// TestRegister tests decoding a tx with the registered decoder
+func TestRegister(t *testing.T) {
+	t.Parallel()
+
+	bobTx, err := bob.NewFromRawTxString(rawBobTx)
+	require.NoError(t, err)
+
+	reg := bob.NewRegistry()
+	require.NoError(t, Register(reg))
+
+	results := bobTx.Decode(reg)
+	require.Empty(t, results.Errors())
+
+	maps := bob.ValuesOf[*MAP](results)
+	require.Len(t, maps, 1)
+	require.Len(t, maps[0].Set, 9)
+	require.Equal(t, "twetch", maps[0].Set["app"])
+	require.Equal(t, "post", maps[0].Set["type"])
+	require.Equal(t, "null", maps[0].Set["reply"])
+}
+
+// ExampleNewFromTapes example using NewFromTapes()
+func ExampleNewFromTapes() {
+	bobTx, err := bob.NewFromRawTxString(rawBobTx)
+	if err != nil {
+		fmt.Printf("error occurred: %s", err.Error())
+		return
+	}
+
+	var m *MAP
+	if m, err = NewFromTapes(bobTx.Tapes()); err != nil {
+		fmt.Printf("error occurred: %s", err.Error())
+		return
+	}
+	fmt.Printf("found app: %s", m.Set["app"])
+	// Output:found app: twetch
+}
+
+// BenchmarkNewFromTapes benchmarks the method NewFromTapes()
+func BenchmarkNewFromTapes(b *testing.B) {
+	tapes := []*bob.Tape{
+		(*bob.Tape)(test.Tape(Prefix, "SET", "app", "twetch", "type", "post")),
+		(*bob.Tape)(test.Tape(Prefix, "ADD", "tags", "a", "b", "c")),
+	}
+	for i := 0; i < b.N; i++ {
+		_, _ = NewFromTapes(tapes)
+	}
+}
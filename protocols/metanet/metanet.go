@@ -0,0 +1,200 @@
+// Package metanet decodes Metanet node tapes
+//
+// Specs: https://nchain.com/app/uploads/2019/06/The-Metanet-Technical-Summary-v1.0.pdf
+//
+// A Metanet tape is made of the prefix, the address of the node key and the
+// txid of the parent node ("NULL" for a root node), optionally followed by
+// the node data:
+//
+//	meta <node address> <parent txid> [<data>...]
+//
+// The tx creating the node must be signed by the node key.
+package metanet
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/bitcoinschema/go-bob"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/bsv-blockchain/go-sdk/script"
+	sighash "github.com/bsv-blockchain/go-sdk/transaction/sighash"
+)
+
+// Prefix is the prefix of Metanet tapes
+const Prefix = "meta"
+
+// RootParent is the parent txid of root nodes
+const RootParent = "NULL"
+
+// Node is a decoded Metanet node
+//
+// ParentTxID is empty for root nodes. Data holds the cells following the
+// parent txid and Children the tapes following the Metanet tape in the
+// same output.
+type Node struct {
+	Address    string      `json:"address"`
+	ParentTxID string      `json:"parent_txid,omitempty"`
+	Data       [][]byte    `json:"data,omitempty"`
+	Children   []*bob.Tape `json:"children,omitempty"`
+}
+
+// NewFromTape decodes a Metanet tape, without its child tapes
+func NewFromTape(tape *bob.Tape) (*Node, error) {
+	if tape == nil {
+		return nil, fmt.Errorf("tape must be set")
+	}
+	if len(tape.Cell) < 3 {
+		return nil, fmt.Errorf("metanet tape must have at least 3 cells, got %d", len(tape.Cell))
+	}
+
+	var fields [3]string
+	for i := range fields {
+		c, err := tape.CellAt(i)
+		if err != nil {
+			return nil, err
+		}
+		if fields[i], err = c.String(); err != nil {
+			return nil, fmt.Errorf("cell %d: %w", i, err)
+		}
+	}
+	if fields[0] != Prefix {
+		return nil, fmt.Errorf("tape prefix %q is not %s", fields[0], Prefix)
+	}
+	if _, err := script.NewAddressFromString(fields[1]); err != nil {
+		return nil, fmt.Errorf("invalid node address %q: %w", fields[1], err)
+	}
+
+	n := &Node{Address: fields[1]}
+	if fields[2] != RootParent {
+		if b, err := hex.DecodeString(fields[2]); err != nil || len(b) != 32 {
+			return nil, fmt.Errorf("invalid parent txid %q", fields[2])
+		}
+		n.ParentTxID = fields[2]
+	}
+
+	for i := 3; i < len(tape.Cell); i++ {
+		c, _ := tape.CellAt(i)
+		data, err := c.Bytes()
+		if err != nil {
+			return nil, fmt.Errorf("cell %d: %w", i, err)
+		}
+		n.Data = append(n.Data, data)
+	}
+	return n, nil
+}
+
+// NewFromTapes decodes the Metanet tape at the index of the tapes of an
+// output, the tapes after it being its children
+func NewFromTapes(tapes []*bob.Tape, index int) (*Node, error) {
+	if index < 0 || index >= len(tapes) {
+		return nil, fmt.Errorf("tape %d out of range", index)
+	}
+	n, err := NewFromTape(tapes[index])
+	if err != nil {
+		return nil, err
+	}
+	if index+1 < len(tapes) {
+		n.Children = tapes[index+1:]
+	}
+	return n, nil
+}
+
+// IsRoot returns true if the node has no parent
+func (n *Node) IsRoot() bool {
+	return n.ParentTxID == ""
+}
+
+// VerifyInput checks the input of the tx is signed by the node key
+//
+// The input must unlock a P2PKH output with <signature> <public key>, the
+// public key being the node key. The output spent by the input is looked up
+// with lookup, or is assumed to be a P2PKH output of E.V satoshis when it is
+// nil or does not know it (see bob.ToTxOptions).
+func (n *Node) VerifyInput(t *bob.Tx, input int, lookup bob.SourceOutputLookup) error {
+	if t == nil {
+		return fmt.Errorf("tx must be set")
+	}
+	if input < 0 || input >= len(t.In) {
+		return fmt.Errorf("input %d out of range", input)
+	}
+
+	var cells []*bob.Cell
+	for i := range t.In[input].Tape {
+		for j := range t.In[input].Tape[i].Cell {
+			cells = append(cells, (*bob.Cell)(&t.In[input].Tape[i].Cell[j]))
+		}
+	}
+	if len(cells) != 2 {
+		return fmt.Errorf("input %d is not a P2PKH unlocking script", input)
+	}
+	sigBytes, err := cells[0].Bytes()
+	if err != nil || len(sigBytes) < 2 {
+		return fmt.Errorf("input %d has no signature", input)
+	}
+	var pubKeyBytes []byte
+	if pubKeyBytes, err = cells[1].Bytes(); err != nil {
+		return fmt.Errorf("input %d has no public key", input)
+	}
+
+	var pubKey *ec.PublicKey
+	if pubKey, err = ec.ParsePubKey(pubKeyBytes); err != nil {
+		return fmt.Errorf("input %d has an invalid public key: %w", input, err)
+	}
+	var address *script.Address
+	if address, err = script.NewAddressFromPublicKeyWithCompression(pubKey, true, len(pubKeyBytes) == 33); err != nil {
+		return err
+	}
+	if address.AddressString != n.Address {
+		return fmt.Errorf("input %d is signed by %s, not the node address %s", input, address.AddressString, n.Address)
+	}
+
+	if lookup == nil && t.In[input].E.V == nil {
+		return fmt.Errorf("input %d satoshis are not set", input)
+	}
+	tx, err := t.ToTxWithOptions(bob.ToTxOptions{SourceOutputs: lookup})
+	if err != nil {
+		return err
+	}
+	if tx.Inputs[input].SourceTxOutput() == nil {
+		return fmt.Errorf("input %d source output is not known", input)
+	}
+
+	var sig *ec.Signature
+	if sig, err = ec.FromDER(sigBytes[:len(sigBytes)-1]); err != nil {
+		return fmt.Errorf("input %d has an invalid signature: %w", input, err)
+	}
+	var hash []byte
+	flag := sighash.Flag(sigBytes[len(sigBytes)-1])
+	if hash, err = tx.CalcInputSignatureHash(uint32(input), flag); err != nil {
+		return err
+	}
+	if !sig.Verify(hash, pubKey) {
+		return fmt.Errorf("input %d signature is not valid", input)
+	}
+	return nil
+}
+
+// Verify returns the index of the first input of the tx signed by the node
+// key (see VerifyInput)
+func (n *Node) Verify(t *bob.Tx, lookup bob.SourceOutputLookup) (int, error) {
+	if t == nil {
+		return -1, fmt.Errorf("tx must be set")
+	}
+	for i := range t.In {
+		if err := n.VerifyInput(t, i, lookup); err == nil {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("no input is signed by the node address %s", n.Address)
+}
+
+// Decode is the bob.TapeDecoder of Metanet tapes
+func Decode(ctx *bob.TapeContext) (any, error) {
+	return NewFromTapes(ctx.Tapes(), ctx.Tape)
+}
+
+// Register registers the Metanet decoder with the registry
+func Register(reg *bob.Registry) error {
+	return reg.Register(Prefix, Decode)
+}
@@ -0,0 +1,213 @@
+package metanet
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/bitcoinschema/go-bob"
+	test "github.com/bitcoinschema/go-bob/testing"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/bsv-blockchain/go-sdk/script"
+	"github.com/bsv-blockchain/go-sdk/transaction"
+	"github.com/bsv-blockchain/go-sdk/transaction/template/p2pkh"
+	"github.com/stretchr/testify/require"
+)
+
+const testParent = "6386afa223e54d4f955e44a1ef4ae5b18bbb8689dff078627a7cb842fad4f7c6"
+
+// testNodeTx returns a tx creating a node of the node key, its input being
+// signed by the signing key, and the output it spends
+func testNodeTx(t testing.TB, node *script.Address, signer *ec.PrivateKey) (*bob.Tx, *transaction.TransactionOutput) {
+	lock, err := p2pkh.Lock(test.Address(signer))
+	require.NoError(t, err)
+	source := transaction.NewTransaction()
+	source.AddOutput(&transaction.TransactionOutput{LockingScript: lock, Satoshis: 1000})
+
+	s := script.NewFromBytes([]byte{})
+	_ = s.AppendOpcodes(script.OpFALSE, script.OpRETURN)
+	for _, push := range []string{Prefix, node.AddressString, testParent, "data"} {
+		_ = s.AppendPushDataString(push)
+	}
+	_ = s.AppendPushDataString("|")
+	_ = s.AppendPushDataString("19HxigV4QyBv3tHpQVcUEQyq1pzZVdoAut")
+	_ = s.AppendPushDataString("hello")
+
+	tx := transaction.NewTransaction()
+	unlock, err := p2pkh.Unlock(signer, nil)
+	require.NoError(t, err)
+	require.NoError(t, tx.AddInputFrom(source.TxID().String(), 0, lock.String(), 1000, unlock))
+	tx.AddOutput(&transaction.TransactionOutput{LockingScript: s})
+	require.NoError(t, tx.Sign())
+
+	bobTx, err := bob.NewFromRawTxString(tx.String())
+	require.NoError(t, err)
+	return bobTx, source.Outputs[0]
+}
+
+// TestNewFromTape tests the method NewFromTape()
+func TestNewFromTape(t *testing.T) {
+	t.Parallel()
+
+	address := test.Address(test.Key(1))
+
+	var (
+		// Testing root and child nodes and invalid tapes
+		tests = []struct {
+			name          string
+			tape          *bob.Tape
+			expected      *Node
+			expectedError bool
+		}{
+			{"root", (*bob.Tape)(test.Tape(Prefix, address.AddressString, RootParent)), &Node{Address: address.AddressString}, false},
+			{"child", (*bob.Tape)(test.Tape(Prefix, address.AddressString, testParent)), &Node{Address: address.AddressString, ParentTxID: testParent}, false},
+			{"data", (*bob.Tape)(test.Tape(Prefix, address.AddressString, testParent, "a", "b")), &Node{
+				Address:    address.AddressString,
+				ParentTxID: testParent,
+				Data:       [][]byte{[]byte("a"), []byte("b")},
+			}, false},
+			{"bad address", (*bob.Tape)(test.Tape(Prefix, "address", testParent)), nil, true},
+			{"bad parent", (*bob.Tape)(test.Tape(Prefix, address.AddressString, "abcd")), nil, true},
+			{"too few cells", (*bob.Tape)(test.Tape(Prefix, address.AddressString)), nil, true},
+			{"wrong prefix", (*bob.Tape)(test.Tape("1BAPSuaPnfGnSBM3GLV9yhxUdYe4vGbdMT", address.AddressString, testParent)), nil, true},
+			{"nil", nil, nil, true},
+		}
+	)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			n, err := NewFromTape(test.tape)
+			if test.expectedError {
+				require.Error(t, err)
+				require.Nil(t, n)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expected, n)
+			require.Equal(t, test.expected.ParentTxID == "", n.IsRoot())
+		})
+	}
+}
+
+// TestNewFromTapes tests decoding a node with its child tapes
+func TestNewFromTapes(t *testing.T) {
+	t.Parallel()
+
+	key := test.Key(1)
+	address := test.Address(key)
+	bobTx, _ := testNodeTx(t, address, key)
+
+	n, err := NewFromTapes(bobTx.Tapes(), 1)
+	require.NoError(t, err)
+	require.Equal(t, address.AddressString, n.Address)
+	require.Equal(t, testParent, n.ParentTxID)
+	require.Equal(t, [][]byte{[]byte("data")}, n.Data)
+	require.Len(t, n.Children, 1)
+
+	prefix, err := n.Children[0].Prefix()
+	require.NoError(t, err)
+	require.Equal(t, "19HxigV4QyBv3tHpQVcUEQyq1pzZVdoAut", prefix)
+
+	_, err = NewFromTapes(bobTx.Tapes(), 5)
+	require.Error(t, err)
+}
+
+// TestNode_VerifyInput tests checking the node signature against the input
+func TestNode_VerifyInput(t *testing.T) {
+	t.Parallel()
+
+	key, other := test.Key(1), test.Key(2)
+	address := test.Address(key)
+
+	t.Run("with lookup", func(t *testing.T) {
+		bobTx, source := testNodeTx(t, address, key)
+		n, err := NewFromTapes(bobTx.Tapes(), 1)
+		require.NoError(t, err)
+
+		lookup := func(string, uint32) (*transaction.TransactionOutput, error) { return source, nil }
+		require.NoError(t, n.VerifyInput(bobTx, 0, lookup))
+
+		var input int
+		input, err = n.Verify(bobTx, lookup)
+		require.NoError(t, err)
+		require.Equal(t, 0, input)
+
+		// the signature commits to the outputs
+		s := "other"
+		bobTx.Out[0].Tape[1].Cell[3].S, bobTx.Out[0].Tape[1].Cell[3].H, bobTx.Out[0].Tape[1].Cell[3].B = &s, nil, nil
+		require.Error(t, n.VerifyInput(bobTx, 0, lookup))
+	})
+
+	t.Run("with satoshis", func(t *testing.T) {
+		bobTx, _ := testNodeTx(t, address, key)
+		n, err := NewFromTapes(bobTx.Tapes(), 1)
+		require.NoError(t, err)
+		require.Error(t, n.VerifyInput(bobTx, 0, nil))
+
+		v := uint64(1000)
+		bobTx.In[0].E.V = &v
+		require.NoError(t, n.VerifyInput(bobTx, 0, nil))
+
+		// wrong amount
+		v = 999
+		require.Error(t, n.VerifyInput(bobTx, 0, nil))
+	})
+
+	t.Run("other signer", func(t *testing.T) {
+		bobTx, source := testNodeTx(t, address, other)
+		n, err := NewFromTapes(bobTx.Tapes(), 1)
+		require.NoError(t, err)
+
+		lookup := func(string, uint32) (*transaction.TransactionOutput, error) { return source, nil }
+		require.Error(t, n.VerifyInput(bobTx, 0, lookup))
+		_, err = n.Verify(bobTx, lookup)
+		require.Error(t, err)
+	})
+
+	t.Run("out of range", func(t *testing.T) {
+		n := &Node{Address: address.AddressString}
+		require.Error(t, n.VerifyInput(&bob.Tx{}, 0, nil))
+		require.Error(t, n.VerifyInput(nil, 0, nil))
+	})
+}
+
+// TestRegister tests decoding a tx with the registered decoder
+func TestRegister(t *testing.T) {
+	t.Parallel()
+
+	key := test.Key(1)
+	address := test.Address(key)
+	bobTx, _ := testNodeTx(t, address, key)
+
+	reg := bob.NewRegistry()
+	require.NoError(t, Register(reg))
+
+	results := bobTx.Decode(reg)
+	require.Empty(t, results.Errors())
+
+	nodes := bob.ValuesOf[*Node](results)
+	require.Len(t, nodes, 1)
+	require.Equal(t, address.AddressString, nodes[0].Address)
+}
+
+// ExampleNewFromTape example using NewFromTape()
+func ExampleNewFromTape() {
+	n, err := NewFromTape((*bob.Tape)(test.Tape(Prefix, "1BgJe6w5HQtN5ELTWrmuaTvVLxiuPgFJ6p", RootParent)))
+	if err != nil {
+		fmt.Printf("error occurred: %s", err.Error())
+		return
+	}
+	fmt.Printf("node %s is root: %t", n.Address, n.IsRoot())
+	// Output:node 1BgJe6w5HQtN5ELTWrmuaTvVLxiuPgFJ6p is root: true
+}
+
+// BenchmarkNode_VerifyInput benchmarks the method VerifyInput()
+func BenchmarkNode_VerifyInput(b *testing.B) {
+	key := test.Key(1)
+	address := test.Address(key)
+	bobTx, source := testNodeTx(b, address, key)
+	n, _ := NewFromTapes(bobTx.Tapes(), 1)
+	lookup := func(string, uint32) (*transaction.TransactionOutput, error) { return source, nil }
+	for i := 0; i < b.N; i++ {
+		_ = n.VerifyInput(bobTx, 0, lookup)
+	}
+}
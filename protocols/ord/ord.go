@@ -0,0 +1,224 @@
+// Package ord decodes 1Sat Ordinals inscriptions from BOB outputs
+//
+// Specs: https://docs.1satordinals.com/
+//
+// An inscription is an envelope placed before or after a P2PKH script,
+// optionally followed by an OP_RETURN with MAP metadata:
+//
+//	OP_FALSE OP_IF "ord" OP_1 <content type> [<tag> <value>...] OP_0 <content> OP_ENDIF
+package ord
+
+import (
+	"fmt"
+
+	"github.com/bitcoinschema/go-bob"
+	"github.com/bitcoinschema/go-bob/protocols/mapp"
+	"github.com/bitcoinschema/go-bpu"
+	"github.com/bsv-blockchain/go-sdk/script"
+)
+
+// Envelope marker pushed after OP_FALSE OP_IF
+const Envelope = "ord"
+
+// Inscription tags
+const (
+	TagContent      = 0
+	TagContentType  = 1
+	TagMetaprotocol = 7
+)
+
+// Inscription is a decoded 1Sat Ordinals inscription
+//
+// Fields holds the values of tags other than the content type, content
+// and metaprotocol, in order. MAP is set when MAP metadata follows the
+// envelope. Owner is the address of the P2PKH script around the envelope.
+type Inscription struct {
+	Output       uint32           `json:"output"`
+	ContentType  string           `json:"content_type,omitempty"`
+	Content      []byte           `json:"content"`
+	Metaprotocol string           `json:"metaprotocol,omitempty"`
+	Fields       map[int][][]byte `json:"fields,omitempty"`
+	MAP          *mapp.MAP        `json:"map,omitempty"`
+	Owner        string           `json:"owner,omitempty"`
+}
+
+// DecodedInscription is the result of decoding the envelope of a single
+// output, either Inscription or Err is set
+type DecodedInscription struct {
+	Output      uint32
+	Inscription *Inscription
+	Err         error
+}
+
+// DecodedInscriptions are the results of decoding the outputs of a tx, in output order
+type DecodedInscriptions []DecodedInscription
+
+// Errors returns the errors of the outputs whose envelope failed to decode
+func (d DecodedInscriptions) Errors() []error {
+	var errs []error
+	for i := range d {
+		if d[i].Err != nil {
+			errs = append(errs, d[i].Err)
+		}
+	}
+	return errs
+}
+
+// Inscriptions returns the successfully decoded inscriptions, keyed by output index
+func (d DecodedInscriptions) Inscriptions() map[uint32]*Inscription {
+	inscriptions := make(map[uint32]*Inscription)
+	for i := range d {
+		if d[i].Err == nil {
+			inscriptions[d[i].Output] = d[i].Inscription
+		}
+	}
+	return inscriptions
+}
+
+// NewFromTx decodes the inscriptions of every output of the tx
+//
+// Outputs without an envelope are left out. Outputs whose envelope failed
+// to decode are included with Err set, so one broken envelope does not hide
+// the inscriptions of the other outputs. Only the first envelope of an
+// output is decoded.
+func NewFromTx(t *bob.Tx) (DecodedInscriptions, error) {
+	if t == nil {
+		return nil, fmt.Errorf("tx must be set")
+	}
+
+	var results DecodedInscriptions
+	owners := t.AddressesByOutput()
+	for i := range t.Out {
+		inscription, err := NewFromOutput(&t.Out[i])
+		if err != nil {
+			results = append(results, DecodedInscription{Output: uint32(i), Err: fmt.Errorf("output %d: %w", i, err)})
+			continue
+		}
+		if inscription == nil {
+			continue
+		}
+		inscription.Output = uint32(i)
+		if addresses := owners[uint32(i)]; len(addresses) == 1 {
+			inscription.Owner = addresses[0]
+		}
+		results = append(results, DecodedInscription{Output: uint32(i), Inscription: inscription})
+	}
+	return results, nil
+}
+
+// NewFromOutput decodes the inscription of an output, or returns nil if
+// it has no envelope
+//
+// Output and Owner are only set by NewFromTx (the output index of bpu is
+// a uint8 and wraps on txs with more than 255 outputs).
+func NewFromOutput(out *bpu.Output) (*Inscription, error) {
+	if out == nil {
+		return nil, fmt.Errorf("output must be set")
+	}
+
+	var cells []*bob.Cell
+	for i := range out.Tape {
+		for j := range out.Tape[i].Cell {
+			cells = append(cells, (*bob.Cell)(&out.Tape[i].Cell[j]))
+		}
+	}
+
+	start := findEnvelope(cells)
+	if start < 0 {
+		return nil, nil
+	}
+	inscription, err := parseEnvelope(cells[start+3:])
+	if err != nil {
+		return nil, err
+	}
+
+	tapes := make([]*bob.Tape, len(out.Tape))
+	for i := range out.Tape {
+		tapes[i] = (*bob.Tape)(&out.Tape[i])
+	}
+	if hasMAP(tapes) {
+		if inscription.MAP, err = mapp.NewFromTapes(tapes); err != nil {
+			return nil, fmt.Errorf("map: %w", err)
+		}
+	}
+	return inscription, nil
+}
+
+// findEnvelope returns the index of the OP_FALSE starting the envelope, or -1
+func findEnvelope(cells []*bob.Cell) int {
+	for i := 0; i+2 < len(cells); i++ {
+		if !isOp(cells[i], script.OpFALSE) || !isOp(cells[i+1], script.OpIF) {
+			continue
+		}
+		if s, err := cells[i+2].String(); err == nil && s == Envelope {
+			return i
+		}
+	}
+	return -1
+}
+
+// parseEnvelope parses the tag and value pairs following the envelope marker
+// up to OP_ENDIF, the pushes after the content tag being the content
+func parseEnvelope(cells []*bob.Cell) (*Inscription, error) {
+	inscription := &Inscription{}
+	for i := 0; i < len(cells); i++ {
+		if isOp(cells[i], script.OpENDIF) {
+			return inscription, nil
+		}
+
+		tag, err := cells[i].Int()
+		if err != nil {
+			return nil, fmt.Errorf("envelope cell %d: invalid tag: %w", i, err)
+		}
+		if tag == TagContent {
+			for i++; i < len(cells) && !isOp(cells[i], script.OpENDIF); i++ {
+				var data []byte
+				if data, err = cells[i].Bytes(); err != nil {
+					return nil, fmt.Errorf("envelope cell %d: %w", i, err)
+				}
+				inscription.Content = append(inscription.Content, data...)
+			}
+			if i == len(cells) {
+				break
+			}
+			return inscription, nil
+		}
+
+		if i+1 == len(cells) {
+			break
+		}
+		i++
+		var value []byte
+		if value, err = cells[i].Bytes(); err != nil {
+			return nil, fmt.Errorf("envelope cell %d: tag %d: %w", i, tag, err)
+		}
+		switch tag {
+		case TagContentType:
+			inscription.ContentType = string(value)
+		case TagMetaprotocol:
+			inscription.Metaprotocol = string(value)
+		default:
+			if inscription.Fields == nil {
+				inscription.Fields = make(map[int][][]byte)
+			}
+			inscription.Fields[int(tag)] = append(inscription.Fields[int(tag)], value)
+		}
+	}
+	return nil, fmt.Errorf("envelope is missing OP_ENDIF")
+}
+
+// hasMAP returns true if any of the tapes is a MAP tape
+func hasMAP(tapes []*bob.Tape) bool {
+	for _, tape := range tapes {
+		if prefix, err := tape.Prefix(); err == nil && prefix == mapp.Prefix {
+			return true
+		}
+	}
+	return false
+}
+
+// isOp returns true if the cell is the given opcode
+func isOp(c *bob.Cell, op uint8) bool {
+	cellOp, ok := c.Opcode()
+	return ok && cellOp == op
+}
@@ -0,0 +1,300 @@
+package ord
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/bitcoinschema/go-bob"
+	"github.com/bitcoinschema/go-bob/protocols/mapp"
+	test "github.com/bitcoinschema/go-bob/testing"
+	"github.com/bsv-blockchain/go-sdk/script"
+	"github.com/bsv-blockchain/go-sdk/transaction"
+	"github.com/bsv-blockchain/go-sdk/transaction/template/p2pkh"
+	"github.com/stretchr/testify/require"
+)
+
+var rawBobTx = test.GetTestHex("../../testing/tx/2.hex")
+
+// testP2PKH returns the P2PKH script and address of a deterministic key
+func testP2PKH(t testing.TB, seed int) (*script.Script, string) {
+	address := test.Address(test.Key(seed))
+	s, err := p2pkh.Lock(address)
+	require.NoError(t, err)
+	return s, address.AddressString
+}
+
+// testOutput parses a tx with the script as its only output
+func testOutput(t testing.TB, s *script.Script) *bob.Tx {
+	tx := transaction.NewTransaction()
+	tx.AddOutput(&transaction.TransactionOutput{LockingScript: s, Satoshis: 1})
+	bobTx, err := bob.NewFromRawTxString(tx.String())
+	require.NoError(t, err)
+	return bobTx
+}
+
+// TestNewFromTx tests decoding inscriptions placed around P2PKH scripts
+func TestNewFromTx(t *testing.T) {
+	t.Parallel()
+
+	p2pkh1, address1 := testP2PKH(t, 1)
+	p2pkh2, address2 := testP2PKH(t, 2)
+	tx := transaction.NewTransaction()
+
+	// 0: envelope followed by P2PKH and MAP metadata
+	s := script.NewFromBytes([]byte{})
+	test.AppendInscription(s, "text/plain", []byte("hello"))
+	*s = append(*s, *p2pkh1...)
+	_ = s.AppendOpcodes(script.OpRETURN)
+	for _, push := range []string{mapp.Prefix, "SET", "app", "test", "type", "ord"} {
+		_ = s.AppendPushDataString(push)
+	}
+	tx.AddOutput(&transaction.TransactionOutput{LockingScript: s, Satoshis: 1})
+
+	// 1: P2PKH followed by an envelope with extra tags and split binary content
+	s = script.NewFromBytes(append([]byte{}, *p2pkh2...))
+	_ = s.AppendOpcodes(script.OpFALSE, script.OpIF)
+	_ = s.AppendPushDataString(Envelope)
+	_ = s.AppendPushData([]byte{TagContentType})
+	_ = s.AppendPushDataString("image/png")
+	_ = s.AppendPushData([]byte{TagMetaprotocol})
+	_ = s.AppendPushDataString("bsv-20")
+	_ = s.AppendOpcodes(script.Op3)
+	_ = s.AppendPushDataString("parent")
+	_ = s.AppendOpcodes(script.Op0)
+	_ = s.AppendPushData([]byte{0x89, 0x50})
+	_ = s.AppendPushData([]byte{0x4e, 0x47})
+	_ = s.AppendOpcodes(script.OpENDIF)
+	tx.AddOutput(&transaction.TransactionOutput{LockingScript: s, Satoshis: 1})
+
+	// 2: plain P2PKH
+	tx.AddOutput(&transaction.TransactionOutput{LockingScript: p2pkh1, Satoshis: 1000})
+
+	// 3: envelope without content or P2PKH
+	s = script.NewFromBytes([]byte{})
+	_ = s.AppendOpcodes(script.OpFALSE, script.OpIF)
+	_ = s.AppendPushDataString(Envelope)
+	_ = s.AppendOpcodes(script.Op1)
+	_ = s.AppendPushDataString("text/plain")
+	_ = s.AppendOpcodes(script.OpENDIF)
+	tx.AddOutput(&transaction.TransactionOutput{LockingScript: s, Satoshis: 1})
+
+	// 4: envelope missing OP_ENDIF
+	s = script.NewFromBytes([]byte{})
+	_ = s.AppendOpcodes(script.OpFALSE, script.OpIF)
+	_ = s.AppendPushDataString(Envelope)
+	_ = s.AppendOpcodes(script.Op1)
+	_ = s.AppendPushDataString("text/plain")
+	tx.AddOutput(&transaction.TransactionOutput{LockingScript: s, Satoshis: 1})
+
+	bobTx, err := bob.NewFromRawTxString(tx.String())
+	require.NoError(t, err)
+
+	var decoded DecodedInscriptions
+	decoded, err = NewFromTx(bobTx)
+	require.NoError(t, err)
+	require.Len(t, decoded, 4)
+
+	// the broken envelope does not hide the other inscriptions
+	require.Equal(t, uint32(4), decoded[3].Output)
+	require.Nil(t, decoded[3].Inscription)
+	require.EqualError(t, decoded[3].Err, "output 4: envelope is missing OP_ENDIF")
+	require.Equal(t, []error{decoded[3].Err}, decoded.Errors())
+
+	inscriptions := decoded.Inscriptions()
+	require.Len(t, inscriptions, 3)
+
+	first := inscriptions[0]
+	require.Equal(t, uint32(0), first.Output)
+	require.Equal(t, "text/plain", first.ContentType)
+	require.Equal(t, []byte("hello"), first.Content)
+	require.Equal(t, address1, first.Owner)
+	require.NotNil(t, first.MAP)
+	require.Equal(t, map[string]string{"app": "test", "type": "ord"}, first.MAP.Set)
+
+	second := inscriptions[1]
+	require.Equal(t, "image/png", second.ContentType)
+	require.Equal(t, []byte{0x89, 0x50, 0x4e, 0x47}, second.Content)
+	require.Equal(t, "bsv-20", second.Metaprotocol)
+	require.Equal(t, map[int][][]byte{3: {[]byte("parent")}}, second.Fields)
+	require.Equal(t, address2, second.Owner)
+	require.Nil(t, second.MAP)
+
+	third := inscriptions[3]
+	require.Equal(t, "text/plain", third.ContentType)
+	require.Empty(t, third.Content)
+	require.Empty(t, third.Owner)
+
+	_, err = NewFromTx(nil)
+	require.Error(t, err)
+}
+
+// TestNewFromTx_ManyOutputs tests a tx with more outputs than a uint8 index can hold in shallow mode
+func TestNewFromTx_ManyOutputs(t *testing.T) {
+	t.Parallel()
+
+	p2pkhScript, address := testP2PKH(t, 1)
+	tx := transaction.NewTransaction()
+	for i := 0; i < 300; i++ {
+		s := script.NewFromBytes([]byte{})
+		test.AppendInscription(s, "text/plain", []byte(fmt.Sprintf("%d", i)))
+		*s = append(*s, *p2pkhScript...)
+		tx.AddOutput(&transaction.TransactionOutput{LockingScript: s, Satoshis: 1})
+	}
+
+	bobTx, err := bob.NewFromRawTxString(tx.String())
+	require.NoError(t, err)
+
+	var decoded DecodedInscriptions
+	decoded, err = NewFromTx(bobTx)
+	require.NoError(t, err)
+	require.Empty(t, decoded.Errors())
+
+	inscriptions := decoded.Inscriptions()
+	require.Len(t, inscriptions, 300)
+	require.Equal(t, uint32(299), inscriptions[299].Output)
+	require.Equal(t, []byte("299"), inscriptions[299].Content)
+	require.Equal(t, address, inscriptions[299].Owner)
+}
+
+// TestNewFromOutput tests outputs without an envelope and broken envelopes
+func TestNewFromOutput(t *testing.T) {
+	t.Parallel()
+
+	var (
+		// Testing scripts that are not valid inscriptions
+		tests = []struct {
+			name          string
+			script        func() *script.Script
+			expectedNil   bool
+			expectedError bool
+		}{
+			{"no envelope", func() *script.Script {
+				s, _ := testP2PKH(t, 1)
+				return s
+			}, true, false},
+			{"not ord", func() *script.Script {
+				s := script.NewFromBytes([]byte{})
+				_ = s.AppendOpcodes(script.OpFALSE, script.OpIF)
+				_ = s.AppendPushDataString("other")
+				_ = s.AppendOpcodes(script.OpENDIF)
+				return s
+			}, true, false},
+			{"missing OP_ENDIF", func() *script.Script {
+				s := script.NewFromBytes([]byte{})
+				_ = s.AppendOpcodes(script.OpFALSE, script.OpIF)
+				_ = s.AppendPushDataString(Envelope)
+				_ = s.AppendOpcodes(script.Op1)
+				_ = s.AppendPushDataString("text/plain")
+				return s
+			}, false, true},
+			{"missing OP_ENDIF after content", func() *script.Script {
+				s := script.NewFromBytes([]byte{})
+				_ = s.AppendOpcodes(script.OpFALSE, script.OpIF)
+				_ = s.AppendPushDataString(Envelope)
+				_ = s.AppendOpcodes(script.Op0)
+				_ = s.AppendPushDataString("hello")
+				return s
+			}, false, true},
+			{"invalid tag", func() *script.Script {
+				s := script.NewFromBytes([]byte{})
+				_ = s.AppendOpcodes(script.OpFALSE, script.OpIF)
+				_ = s.AppendPushDataString(Envelope)
+				_ = s.AppendOpcodes(script.OpDUP)
+				_ = s.AppendPushDataString("text/plain")
+				_ = s.AppendOpcodes(script.OpENDIF)
+				return s
+			}, false, true},
+			{"invalid MAP", func() *script.Script {
+				s := script.NewFromBytes([]byte{})
+				test.AppendInscription(s, "text/plain", []byte("hello"))
+				_ = s.AppendOpcodes(script.OpRETURN)
+				_ = s.AppendPushDataString(mapp.Prefix)
+				_ = s.AppendPushDataString("SET")
+				return s
+			}, false, true},
+		}
+	)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			bobTx := testOutput(t, test.script())
+			inscription, err := NewFromOutput(&bobTx.Out[0])
+			if test.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expectedNil, inscription == nil)
+		})
+	}
+
+	_, err := NewFromOutput(nil)
+	require.Error(t, err)
+}
+
+// TestNewFromOutput_LargeContent tests content larger than a single pushdata prefix byte
+func TestNewFromOutput_LargeContent(t *testing.T) {
+	t.Parallel()
+
+	content := bytes.Repeat([]byte{0xab}, 100000)
+	s := script.NewFromBytes([]byte{})
+	test.AppendInscription(s, "application/octet-stream", content)
+	bobTx := testOutput(t, s)
+
+	inscription, err := NewFromOutput(&bobTx.Out[0])
+	require.NoError(t, err)
+	require.Equal(t, content, inscription.Content)
+}
+
+// TestNewFromTx_NoInscriptions tests a tx without inscriptions
+func TestNewFromTx_NoInscriptions(t *testing.T) {
+	t.Parallel()
+
+	bobTx, err := bob.NewFromRawTxString(rawBobTx)
+	require.NoError(t, err)
+
+	inscriptions, err := NewFromTx(bobTx)
+	require.NoError(t, err)
+	require.Empty(t, inscriptions)
+}
+
+// ExampleNewFromTx example using NewFromTx()
+func ExampleNewFromTx() {
+	s := script.NewFromBytes([]byte{})
+	test.AppendInscription(s, "text/plain", []byte("hello world"))
+	tx := transaction.NewTransaction()
+	tx.AddOutput(&transaction.TransactionOutput{LockingScript: s, Satoshis: 1})
+
+	bobTx, err := bob.NewFromRawTxString(tx.String())
+	if err != nil {
+		fmt.Printf("error occurred: %s", err.Error())
+		return
+	}
+
+	var decoded DecodedInscriptions
+	if decoded, err = NewFromTx(bobTx); err != nil {
+		fmt.Printf("error occurred: %s", err.Error())
+		return
+	}
+	for _, d := range decoded {
+		if d.Err != nil {
+			fmt.Printf("error occurred: %s", d.Err.Error())
+			continue
+		}
+		fmt.Printf("found %s: %s", d.Inscription.ContentType, d.Inscription.Content)
+	}
+	// Output:found text/plain: hello world
+}
+
+// BenchmarkNewFromTx benchmarks the method NewFromTx()
+func BenchmarkNewFromTx(b *testing.B) {
+	p2pkhScript, _ := testP2PKH(b, 1)
+	s := script.NewFromBytes([]byte{})
+	test.AppendInscription(s, "text/plain", []byte("hello"))
+	*s = append(*s, *p2pkhScript...)
+	bobTx := testOutput(b, s)
+	for i := 0; i < b.N; i++ {
+		_, _ = NewFromTx(bobTx)
+	}
+}
@@ -0,0 +1,167 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bitcoinschema/go-bob"
+)
+
+// Document returns the generic document queries are evaluated against
+//
+// It is the JSON form of the tx, every input and output also having the
+// legacy flattened cells of its script (see bob.Tx.Flatten), such as out.s2.
+func Document(t *bob.Tx) (map[string]any, error) {
+	if t == nil {
+		return nil, fmt.Errorf("tx must be set")
+	}
+	doc, err := toGeneric(t)
+	if err != nil {
+		return nil, err
+	}
+	var flat map[string]any
+	if flat, err = t.Flatten(); err != nil {
+		return nil, err
+	}
+	if flat, err = toGeneric(flat); err != nil {
+		return nil, err
+	}
+
+	for _, key := range []string{"in", "out"} {
+		xputs, _ := doc[key].([]any)
+		flatXPuts, _ := flat[key].([]any)
+		for i := range xputs {
+			xput, _ := xputs[i].(map[string]any)
+			if xput == nil || i >= len(flatXPuts) {
+				continue
+			}
+			flatXPut, _ := flatXPuts[i].(map[string]any)
+			for k, v := range flatXPut {
+				if _, ok := xput[k]; !ok {
+					xput[k] = v
+				}
+			}
+		}
+	}
+	return doc, nil
+}
+
+// toGeneric returns the generic JSON form of v
+func toGeneric(v any) (map[string]any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]any
+	if err = json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// resolve returns the values at the dotted path, traversing arrays (any
+// element may match, or a numeric part picks an element)
+func resolve(v any, path []string) []any {
+	if len(path) == 0 {
+		return []any{v}
+	}
+	switch t := v.(type) {
+	case map[string]any:
+		next, ok := t[path[0]]
+		if !ok {
+			return nil
+		}
+		return resolve(next, path[1:])
+	case []any:
+		if i, err := strconv.Atoi(path[0]); err == nil {
+			if i < 0 || i >= len(t) {
+				return nil
+			}
+			return resolve(t[i], path[1:])
+		}
+		var values []any
+		for _, e := range t {
+			values = append(values, resolve(e, path)...)
+		}
+		return values
+	}
+	return nil
+}
+
+// splitPath splits a dotted path
+func splitPath(path string) []string {
+	return strings.Split(path, ".")
+}
+
+// include returns the parts of v at the paths, keeping its structure
+func include(v any, paths [][]string) any {
+	switch t := v.(type) {
+	case map[string]any:
+		children := make(map[string][][]string)
+		out := make(map[string]any)
+		for _, path := range paths {
+			if _, ok := t[path[0]]; !ok {
+				continue
+			}
+			if len(path) == 1 {
+				out[path[0]] = t[path[0]]
+				continue
+			}
+			children[path[0]] = append(children[path[0]], path[1:])
+		}
+		for key, sub := range children {
+			if _, ok := out[key]; ok {
+				continue
+			}
+			if projected := include(t[key], sub); projected != nil {
+				out[key] = projected
+			}
+		}
+		return out
+	case []any:
+		out := make([]any, 0, len(t))
+		for _, e := range t {
+			if projected := include(e, paths); projected != nil {
+				out = append(out, projected)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// exclude returns a copy of v without the paths
+func exclude(v any, paths [][]string) any {
+	switch t := v.(type) {
+	case map[string]any:
+		children := make(map[string][][]string)
+		removed := make(map[string]bool)
+		for _, path := range paths {
+			if len(path) == 1 {
+				removed[path[0]] = true
+				continue
+			}
+			children[path[0]] = append(children[path[0]], path[1:])
+		}
+		out := make(map[string]any, len(t))
+		for key, value := range t {
+			switch {
+			case removed[key]:
+			case children[key] != nil:
+				out[key] = exclude(value, children[key])
+			default:
+				out[key] = value
+			}
+		}
+		return out
+	case []any:
+		out := make([]any, len(t))
+		for i, e := range t {
+			out[i] = exclude(e, paths)
+		}
+		return out
+	}
+	return v
+}
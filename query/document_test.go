@@ -0,0 +1,63 @@
+package query
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/bitcoinschema/go-bob"
+	"github.com/bitcoinschema/go-bpu"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDocument tests the method Document()
+func TestDocument(t *testing.T) {
+	t.Parallel()
+
+	t.Run("legacy cells", func(t *testing.T) {
+		doc, err := Document(testTxs(t)[1])
+		require.NoError(t, err)
+		out := doc["out"].([]any)[0].(map[string]any)
+		require.Equal(t, map[string]any{"op": float64(0)}, out["b0"])
+		require.Equal(t, map[string]any{"op": float64(106)}, out["b1"])
+		require.Equal(t, "ATTEST", out["s3"])
+		require.Equal(t, "415454455354", out["h3"])
+		require.Equal(t, "QVRURVNU", out["b3"])
+	})
+
+	t.Run("large pushdata", func(t *testing.T) {
+		b, s := "AQI=", "\x01\x02"
+		tx := &bob.Tx{}
+		tx.Out = []bpu.Output{{XPut: bpu.XPut{Tape: []bpu.Tape{{Cell: []bpu.Cell{{LB: &b, LS: &s, II: 2}}}}}}}
+		doc, err := Document(tx)
+		require.NoError(t, err)
+		out := doc["out"].([]any)[0].(map[string]any)
+		require.Equal(t, b, out["lb2"])
+		require.Equal(t, "0102", out["lh2"])
+		require.Equal(t, s, out["ls2"])
+		require.NotContains(t, out, "b2")
+	})
+
+	t.Run("nil tx", func(t *testing.T) {
+		_, err := Document(nil)
+		require.Error(t, err)
+	})
+}
+
+// TestProjection tests the methods include() and exclude()
+func TestProjection(t *testing.T) {
+	t.Parallel()
+
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal([]byte(testDoc), &doc))
+
+	require.Equal(t, map[string]any{
+		"tx":  map[string]any{"h": "abc"},
+		"out": []any{map[string]any{"s1": "hello"}, map[string]any{"s1": "world"}},
+	}, include(doc, [][]string{{"tx", "h"}, {"out", "s1"}, {"missing"}}))
+
+	require.Equal(t, map[string]any{
+		"blk":  map[string]any{"i": float64(10)},
+		"tags": []any{"a", "b"},
+		"out":  []any{map[string]any{"i": float64(0)}, map[string]any{"i": float64(1)}},
+	}, exclude(doc, [][]string{{"tx"}, {"out", "s1"}, {"out", "e"}}))
+}
@@ -0,0 +1,281 @@
+package query
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// filter matches documents
+type filter interface {
+	match(doc any) bool
+}
+
+// andFilter matches documents matching all of its filters
+type andFilter []filter
+
+func (f andFilter) match(doc any) bool {
+	for _, sub := range f {
+		if !sub.match(doc) {
+			return false
+		}
+	}
+	return true
+}
+
+// orFilter matches documents matching any of its filters
+type orFilter []filter
+
+func (f orFilter) match(doc any) bool {
+	for _, sub := range f {
+		if sub.match(doc) {
+			return true
+		}
+	}
+	return false
+}
+
+// norFilter matches documents matching none of its filters
+type norFilter []filter
+
+func (f norFilter) match(doc any) bool {
+	return !orFilter(f).match(doc)
+}
+
+// condition matches the values found at a path
+type condition func(values []any) bool
+
+// fieldFilter matches documents whose values at the path meet all conditions
+type fieldFilter struct {
+	path       []string
+	conditions []condition
+}
+
+func (f *fieldFilter) match(doc any) bool {
+	values := resolve(doc, f.path)
+	for _, c := range f.conditions {
+		if !c(values) {
+			return false
+		}
+	}
+	return true
+}
+
+// compileFind compiles a find document, in the MongoDB query syntax
+func compileFind(find map[string]any) (filter, error) {
+	var f andFilter
+	for key, value := range find {
+		switch key {
+		case "$and", "$or", "$nor":
+			list, ok := value.([]any)
+			if !ok || len(list) == 0 {
+				return nil, fmt.Errorf("%s must be a non-empty array", key)
+			}
+			subs := make([]filter, len(list))
+			for i, e := range list {
+				doc, ok := e.(map[string]any)
+				if !ok {
+					return nil, fmt.Errorf("%s element %d must be an object", key, i)
+				}
+				var err error
+				if subs[i], err = compileFind(doc); err != nil {
+					return nil, err
+				}
+			}
+			switch key {
+			case "$and":
+				f = append(f, andFilter(subs))
+			case "$or":
+				f = append(f, orFilter(subs))
+			default:
+				f = append(f, norFilter(subs))
+			}
+		default:
+			if strings.HasPrefix(key, "$") {
+				return nil, fmt.Errorf("unsupported operator %s", key)
+			}
+			conditions, err := compileConditions(key, value)
+			if err != nil {
+				return nil, err
+			}
+			f = append(f, &fieldFilter{path: splitPath(key), conditions: conditions})
+		}
+	}
+	return f, nil
+}
+
+// compileConditions compiles the value of a field, either a value to be
+// equal to or an object of operators
+func compileConditions(field string, value any) ([]condition, error) {
+	ops, ok := value.(map[string]any)
+	if !ok || !isOperators(ops) {
+		return []condition{equals(value)}, nil
+	}
+
+	var conditions []condition
+	for op, arg := range ops {
+		switch op {
+		case "$eq":
+			conditions = append(conditions, equals(arg))
+		case "$ne":
+			eq := equals(arg)
+			conditions = append(conditions, func(values []any) bool { return !eq(values) })
+		case "$in", "$nin":
+			list, ok := arg.([]any)
+			if !ok {
+				return nil, fmt.Errorf("%s: %s must be an array", field, op)
+			}
+			in := inList(list)
+			if op == "$nin" {
+				conditions = append(conditions, func(values []any) bool { return !in(values) })
+			} else {
+				conditions = append(conditions, in)
+			}
+		case "$exists":
+			exists, ok := arg.(bool)
+			if !ok {
+				return nil, fmt.Errorf("%s: $exists must be a boolean", field)
+			}
+			conditions = append(conditions, func(values []any) bool { return (len(values) > 0) == exists })
+		case "$regex":
+			pattern, ok := arg.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s: $regex must be a string", field)
+			}
+			if options, ok := ops["$options"].(string); ok && options != "" {
+				pattern = "(?" + options + ")" + pattern
+			}
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("%s: invalid $regex: %w", field, err)
+			}
+			conditions = append(conditions, matchesRegexp(re))
+		case "$options":
+			if _, ok := ops["$regex"]; !ok {
+				return nil, fmt.Errorf("%s: $options requires $regex", field)
+			}
+		case "$gt", "$gte", "$lt", "$lte":
+			conditions = append(conditions, compares(op, arg))
+		default:
+			return nil, fmt.Errorf("%s: unsupported operator %s", field, op)
+		}
+	}
+	return conditions, nil
+}
+
+// isOperators returns true if the object is made of operators
+func isOperators(obj map[string]any) bool {
+	if len(obj) == 0 {
+		return false
+	}
+	for key := range obj {
+		if !strings.HasPrefix(key, "$") {
+			return false
+		}
+	}
+	return true
+}
+
+// candidates returns the values and the elements of array values, which
+// are matched individually
+func candidates(values []any) []any {
+	var all []any
+	for _, v := range values {
+		all = append(all, v)
+		if list, ok := v.([]any); ok {
+			all = append(all, list...)
+		}
+	}
+	return all
+}
+
+// equals returns a condition matching when any value equals v, a missing
+// value being equal to null
+func equals(v any) condition {
+	return func(values []any) bool {
+		if v == nil && len(values) == 0 {
+			return true
+		}
+		for _, c := range candidates(values) {
+			if reflect.DeepEqual(c, v) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// inList returns a condition matching when any value equals any element of the list
+func inList(list []any) condition {
+	conditions := make([]condition, len(list))
+	for i, e := range list {
+		conditions[i] = equals(e)
+	}
+	return func(values []any) bool {
+		for _, c := range conditions {
+			if c(values) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// matchesRegexp returns a condition matching when any string value matches
+func matchesRegexp(re *regexp.Regexp) condition {
+	return func(values []any) bool {
+		for _, c := range candidates(values) {
+			if s, ok := c.(string); ok && re.MatchString(s) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// compares returns a condition matching when any value of the same type
+// (number or string) compares to v as the operator requires
+func compares(op string, v any) condition {
+	return func(values []any) bool {
+		for _, c := range candidates(values) {
+			cmp, ok := compare(c, v)
+			if !ok {
+				continue
+			}
+			switch {
+			case op == "$gt" && cmp > 0,
+				op == "$gte" && cmp >= 0,
+				op == "$lt" && cmp < 0,
+				op == "$lte" && cmp <= 0:
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// compare compares two numbers or two strings, returning false for other types
+func compare(a, b any) (int, bool) {
+	switch x := a.(type) {
+	case float64:
+		y, ok := b.(float64)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case x < y:
+			return -1, true
+		case x > y:
+			return 1, true
+		}
+		return 0, true
+	case string:
+		y, ok := b.(string)
+		if !ok {
+			return 0, false
+		}
+		return strings.Compare(x, y), true
+	}
+	return 0, false
+}
@@ -0,0 +1,93 @@
+package query
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testDoc is the document the filters are tested against
+const testDoc = `{
+	"tx": {"h": "abc"},
+	"blk": {"i": 10},
+	"tags": ["a", "b"],
+	"out": [
+		{"i": 0, "s1": "hello", "e": {"v": 0}},
+		{"i": 1, "s1": "world", "e": {"v": 500}}
+	]
+}`
+
+// TestCompileFind tests the filters compiled by compileFind()
+func TestCompileFind(t *testing.T) {
+	t.Parallel()
+
+	var (
+		// Testing every operator against the test document
+		tests = []struct {
+			name     string
+			find     string
+			expected bool
+		}{
+			{"empty", `{}`, true},
+			{"equal", `{"tx.h":"abc"}`, true},
+			{"not equal", `{"tx.h":"abd"}`, false},
+			{"array element", `{"out.s1":"world"}`, true},
+			{"array index", `{"out.1.s1":"world"}`, true},
+			{"array index mismatch", `{"out.0.s1":"world"}`, false},
+			{"array index out of range", `{"out.5.s1":"world"}`, false},
+			{"array value", `{"tags":"b"}`, true},
+			{"whole array", `{"tags":["a","b"]}`, true},
+			{"object", `{"tx":{"h":"abc"}}`, true},
+			{"number", `{"blk.i":10}`, true},
+			{"missing is null", `{"blk.t":null}`, true},
+			{"null is not missing", `{"blk.i":null}`, false},
+			{"$eq", `{"blk.i":{"$eq":10}}`, true},
+			{"$ne", `{"blk.i":{"$ne":10}}`, false},
+			{"$ne missing", `{"blk.t":{"$ne":10}}`, true},
+			{"$in", `{"out.s1":{"$in":["x","hello"]}}`, true},
+			{"$in none", `{"out.s1":{"$in":["x","y"]}}`, false},
+			{"$nin", `{"out.s1":{"$nin":["x","y"]}}`, true},
+			{"$exists", `{"out.e.v":{"$exists":true}}`, true},
+			{"$exists missing", `{"out.e.a":{"$exists":true}}`, false},
+			{"$exists false", `{"out.e.a":{"$exists":false}}`, true},
+			{"$regex", `{"out.s1":{"$regex":"^wor"}}`, true},
+			{"$regex options", `{"out.s1":{"$regex":"^WOR","$options":"i"}}`, true},
+			{"$regex case", `{"out.s1":{"$regex":"^WOR"}}`, false},
+			{"$regex number", `{"blk.i":{"$regex":"10"}}`, false},
+			{"$gt", `{"out.e.v":{"$gt":100}}`, true},
+			{"$gte", `{"blk.i":{"$gte":10}}`, true},
+			{"$lt", `{"blk.i":{"$lt":10}}`, false},
+			{"$lte range", `{"blk.i":{"$gt":5,"$lte":10}}`, true},
+			{"$gt string", `{"tx.h":{"$gt":"abb"}}`, true},
+			{"$gt other type", `{"tx.h":{"$gt":1}}`, false},
+			{"$and", `{"$and":[{"tx.h":"abc"},{"blk.i":10}]}`, true},
+			{"$and mismatch", `{"$and":[{"tx.h":"abc"},{"blk.i":11}]}`, false},
+			{"$or", `{"$or":[{"tx.h":"x"},{"blk.i":10}]}`, true},
+			{"$or mismatch", `{"$or":[{"tx.h":"x"},{"blk.i":11}]}`, false},
+			{"$nor", `{"$nor":[{"tx.h":"x"},{"blk.i":11}]}`, true},
+			{"nested", `{"tx.h":"abc","$or":[{"out.s1":"x"},{"$and":[{"out.s1":"hello"},{"tags":"a"}]}]}`, true},
+		}
+	)
+
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal([]byte(testDoc), &doc))
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var find map[string]any
+			require.NoError(t, json.Unmarshal([]byte(test.find), &find))
+			f, err := compileFind(find)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, f.match(doc))
+		})
+	}
+}
+
+// BenchmarkCompileFind benchmarks the method compileFind()
+func BenchmarkCompileFind(b *testing.B) {
+	var find map[string]any
+	_ = json.Unmarshal([]byte(`{"tx.h":"abc","$or":[{"out.s1":{"$regex":"^wor"}},{"blk.i":{"$gt":5}}]}`), &find)
+	for i := 0; i < b.N; i++ {
+		_, _ = compileFind(find)
+	}
+}
@@ -0,0 +1,325 @@
+// Package query evaluates Bitquery (BitDB query language) queries against
+// BOB transactions
+//
+// Specs: https://bitquery.planaria.network/
+//
+// The find, project, sort, limit and skip parts of Bitquery v3 are supported.
+// Find uses the MongoDB query syntax with the $and, $or, $nor, $eq, $ne,
+// $in, $nin, $gt, $gte, $lt, $lte, $exists and $regex operators, $regex
+// using the Go regexp syntax:
+//
+//	{"v": 3, "q": {"find": {"out.s2": "19HxigV4QyBv3tHpQVcUEQyq1pzZVdoAut"}, "project": {"tx.h": 1}, "limit": 10}}
+//
+// Queries are evaluated against the Document of each tx, so both the BOB
+// paths (out.tape.cell.s) and the legacy flattened paths (out.s2) can be used.
+package query
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/bitcoinschema/go-bob"
+)
+
+// SortField is a field to sort the results by
+type SortField struct {
+	Path       string
+	Descending bool
+}
+
+// Query is a parsed Bitquery query
+type Query struct {
+	Find    map[string]any
+	Project map[string]any
+	Sort    []SortField
+	Limit   int
+	Skip    int
+
+	filter  filter
+	include [][]string
+	exclude [][]string
+}
+
+// bitquery is the JSON form of a query
+type bitquery struct {
+	V int              `json:"v"`
+	Q *json.RawMessage `json:"q"`
+	R json.RawMessage  `json:"r"`
+}
+
+// q is the JSON form of the q part of a query
+type q struct {
+	Find      map[string]any  `json:"find"`
+	Project   map[string]any  `json:"project"`
+	Sort      json.RawMessage `json:"sort"`
+	Limit     *int            `json:"limit"`
+	Skip      *int            `json:"skip"`
+	Aggregate json.RawMessage `json:"aggregate"`
+	DB        json.RawMessage `json:"db"`
+}
+
+// Parse parses a Bitquery query, either the full query or only its q part
+func Parse(data []byte) (*Query, error) {
+	var b bitquery
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("invalid query: %w", err)
+	}
+	if b.V != 0 && b.V != 3 {
+		return nil, fmt.Errorf("unsupported bitquery version %d", b.V)
+	}
+	if len(b.R) > 0 {
+		return nil, fmt.Errorf("response transforms (r) are not supported")
+	}
+	if b.Q != nil {
+		data = *b.Q
+	}
+
+	var raw q
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid query: %w", err)
+	}
+	if len(raw.Aggregate) > 0 {
+		return nil, fmt.Errorf("aggregate is not supported")
+	}
+
+	query := &Query{Find: raw.Find, Project: raw.Project}
+	if raw.Limit != nil {
+		if *raw.Limit < 0 {
+			return nil, fmt.Errorf("limit must not be negative")
+		}
+		query.Limit = *raw.Limit
+	}
+	if raw.Skip != nil {
+		if *raw.Skip < 0 {
+			return nil, fmt.Errorf("skip must not be negative")
+		}
+		query.Skip = *raw.Skip
+	}
+
+	var err error
+	if query.Sort, err = parseSort(raw.Sort); err != nil {
+		return nil, err
+	}
+	if err = query.compile(); err != nil {
+		return nil, err
+	}
+	return query, nil
+}
+
+// parseSort parses the sort object, keeping the order of its fields
+func parseSort(data json.RawMessage) ([]SortField, error) {
+	if len(data) == 0 || string(data) == "null" {
+		return nil, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, fmt.Errorf("sort must be an object")
+	}
+	var fields []SortField
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("invalid sort: %w", err)
+		}
+		path, _ := tok.(string)
+		var direction int
+		if err = dec.Decode(&direction); err != nil || (direction != 1 && direction != -1) {
+			return nil, fmt.Errorf("sort %s must be 1 or -1", path)
+		}
+		fields = append(fields, SortField{Path: path, Descending: direction == -1})
+	}
+	return fields, nil
+}
+
+// compile compiles the find and project parts
+func (q *Query) compile() error {
+	var err error
+	if q.filter, err = compileFind(q.Find); err != nil {
+		return err
+	}
+
+	for path, v := range q.Project {
+		var on bool
+		switch t := v.(type) {
+		case float64:
+			on = t != 0
+		case bool:
+			on = t
+		default:
+			return fmt.Errorf("project %s must be 0, 1 or a boolean", path)
+		}
+		if on {
+			q.include = append(q.include, splitPath(path))
+		} else {
+			q.exclude = append(q.exclude, splitPath(path))
+		}
+	}
+	if len(q.include) > 0 && len(q.exclude) > 0 {
+		return fmt.Errorf("project cannot both include and exclude fields")
+	}
+	return nil
+}
+
+// Match returns true if the tx matches the find part of the query
+func (q *Query) Match(t *bob.Tx) (bool, error) {
+	doc, err := Document(t)
+	if err != nil {
+		return false, err
+	}
+	return q.filter.match(doc), nil
+}
+
+// Exec evaluates the query against the txs, returning the projected documents
+func (q *Query) Exec(txs []*bob.Tx) ([]map[string]any, error) {
+	r := q.newRunner()
+	for _, t := range txs {
+		done, err := r.add(t)
+		if err != nil {
+			return nil, err
+		}
+		if done {
+			break
+		}
+	}
+	return r.results(), nil
+}
+
+// Run evaluates the query against the NDJSON stream of BOB txs (see bob.Decoder)
+//
+// Without sort, reading stops once the limit is reached.
+func (q *Query) Run(r io.Reader) ([]map[string]any, error) {
+	run := q.newRunner()
+	dec := bob.NewDecoder(r)
+	for dec.Next() {
+		done, err := run.add(dec.Tx())
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", dec.Line(), err)
+		}
+		if done {
+			return run.results(), nil
+		}
+	}
+	if err := dec.Err(); err != nil {
+		return nil, err
+	}
+	return run.results(), nil
+}
+
+// runner collects the documents matching a query
+type runner struct {
+	q       *Query
+	docs    []map[string]any
+	skipped int
+}
+
+// newRunner returns a runner for the query
+func (q *Query) newRunner() *runner {
+	return &runner{q: q}
+}
+
+// add evaluates the tx, returning true once no more txs are needed
+func (r *runner) add(t *bob.Tx) (bool, error) {
+	doc, err := Document(t)
+	if err != nil {
+		return false, err
+	}
+	if !r.q.filter.match(doc) {
+		return false, nil
+	}
+	if len(r.q.Sort) > 0 {
+		r.docs = append(r.docs, doc)
+		return false, nil
+	}
+	if r.skipped < r.q.Skip {
+		r.skipped++
+		return false, nil
+	}
+	r.docs = append(r.docs, doc)
+	return r.q.Limit > 0 && len(r.docs) >= r.q.Limit, nil
+}
+
+// results sorts, skips, limits and projects the documents
+func (r *runner) results() []map[string]any {
+	docs := r.docs
+	if len(r.q.Sort) > 0 {
+		sort.SliceStable(docs, func(i, j int) bool {
+			return r.q.less(docs[i], docs[j])
+		})
+		if r.q.Skip >= len(docs) {
+			docs = nil
+		} else {
+			docs = docs[r.q.Skip:]
+		}
+		if r.q.Limit > 0 && len(docs) > r.q.Limit {
+			docs = docs[:r.q.Limit]
+		}
+	}
+
+	results := make([]map[string]any, len(docs))
+	for i, doc := range docs {
+		results[i] = r.q.project(doc)
+	}
+	return results
+}
+
+// project applies the project part of the query to the document
+func (q *Query) project(doc map[string]any) map[string]any {
+	switch {
+	case len(q.include) > 0:
+		projected, _ := include(doc, q.include).(map[string]any)
+		return projected
+	case len(q.exclude) > 0:
+		projected, _ := exclude(doc, q.exclude).(map[string]any)
+		return projected
+	}
+	return doc
+}
+
+// less returns true if document a sorts before document b
+func (q *Query) less(a, b map[string]any) bool {
+	for _, field := range q.Sort {
+		path := splitPath(field.Path)
+		cmp := compareSort(first(resolve(a, path)), first(resolve(b, path)))
+		if cmp == 0 {
+			continue
+		}
+		if field.Descending {
+			return cmp > 0
+		}
+		return cmp < 0
+	}
+	return false
+}
+
+// first returns the first value, or nil if there is none
+func first(values []any) any {
+	if len(values) == 0 {
+		return nil
+	}
+	return values[0]
+}
+
+// compareSort compares two values, missing values sorting first, then
+// numbers, then strings, then other values
+func compareSort(a, b any) int {
+	if cmp, ok := compare(a, b); ok {
+		return cmp
+	}
+	return sortRank(a) - sortRank(b)
+}
+
+// sortRank ranks the types of values for sorting
+func sortRank(v any) int {
+	switch v.(type) {
+	case nil:
+		return 0
+	case float64:
+		return 1
+	case string:
+		return 2
+	}
+	return 3
+}
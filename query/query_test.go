@@ -0,0 +1,268 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bitcoinschema/go-bob"
+	test "github.com/bitcoinschema/go-bob/testing"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	txIDBoost  = "207eaadc096849e037b8944df21a8bba6d91d8445848db047c0a3f963121e19d"
+	txIDAttest = "26b754e6fdf04121b8d91160a0b252a22ae30204fc552605b7f6d3f08419f29e"
+	txIDParity = "98a5f6ef18eaea188bdfdc048f89a48af82627a15a76fd53584975f28ab3cc39"
+)
+
+// testTxs returns the BOB test txs
+func testTxs(t testing.TB) []*bob.Tx {
+	var txs []*bob.Tx
+	for _, txID := range []string{txIDBoost, txIDAttest, txIDParity} {
+		tx, err := bob.NewFromString(test.GetTestHex("../testing/bob/" + txID + ".json"))
+		require.NoError(t, err)
+		txs = append(txs, tx)
+	}
+	return txs
+}
+
+// testNDJSON returns the BOB test txs as an NDJSON stream
+func testNDJSON(t testing.TB) string {
+	var lines []string
+	for _, tx := range testTxs(t) {
+		line, err := json.Marshal(tx)
+		require.NoError(t, err)
+		lines = append(lines, string(line))
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// txIDs returns the tx.h of the documents
+func txIDs(docs []map[string]any) []string {
+	ids := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		ids = append(ids, fmt.Sprint(doc["tx"].(map[string]any)["h"]))
+	}
+	return ids
+}
+
+// TestQuery_Exec tests the method Exec()
+func TestQuery_Exec(t *testing.T) {
+	t.Parallel()
+
+	var (
+		// Testing find, sort, skip and limit against the test txs
+		tests = []struct {
+			name     string
+			query    string
+			expected []string
+		}{
+			{"empty find", `{"v":3,"q":{"find":{}}}`, []string{txIDBoost, txIDAttest, txIDParity}},
+			{"q only", `{"find":{}}`, []string{txIDBoost, txIDAttest, txIDParity}},
+			{"tx id", `{"v":3,"q":{"find":{"tx.h":"` + txIDAttest + `"}}}`, []string{txIDAttest}},
+			{"legacy cell", `{"v":3,"q":{"find":{"out.s2":"ATTEST"}}}`, []string{txIDParity}},
+			{"legacy cell index", `{"v":3,"q":{"find":{"out.0.s3":"ATTEST"}}}`, []string{txIDAttest}},
+			{"tape cell", `{"v":3,"q":{"find":{"out.tape.cell.s":"ATTEST"}}}`, []string{txIDAttest, txIDParity}},
+			{"legacy opcode", `{"v":3,"q":{"find":{"out.b0.op":0,"out.b1.op":106}}}`, []string{txIDBoost, txIDAttest}},
+			{"$or", `{"v":3,"q":{"find":{"$or":[{"out.s2":"ATTEST"},{"out.s3":"ATTEST"}]}}}`, []string{txIDAttest, txIDParity}},
+			{"$and", `{"v":3,"q":{"find":{"$and":[{"out.tape.cell.s":"ATTEST"},{"out.s2":"ATTEST"}]}}}`, []string{txIDParity}},
+			{"$nor", `{"v":3,"q":{"find":{"$nor":[{"out.s2":"ATTEST"},{"out.s3":"ATTEST"}]}}}`, []string{txIDBoost}},
+			{"$in", `{"v":3,"q":{"find":{"out.e.a":{"$in":["1FFuYLM8a66GddCG25nUbarazeMr5dnUwC","1invalid"]}}}}`, []string{txIDBoost}},
+			{"$nin", `{"v":3,"q":{"find":{"out.e.a":{"$nin":["1FFuYLM8a66GddCG25nUbarazeMr5dnUwC"]}}}}`, []string{txIDAttest, txIDParity}},
+			{"$regex", `{"v":3,"q":{"find":{"out.s2":{"$regex":"^attest$","$options":"i"}}}}`, []string{txIDParity}},
+			{"$exists", `{"v":3,"q":{"find":{"out.s10":{"$exists":true}}}}`, []string{txIDAttest}},
+			{"$exists false", `{"v":3,"q":{"find":{"out.s10":{"$exists":false}}}}`, []string{txIDBoost, txIDParity}},
+			{"$gt", `{"v":3,"q":{"find":{"blk.i":{"$gt":600000}}}}`, []string{txIDBoost}},
+			{"$ne", `{"v":3,"q":{"find":{"tx.h":{"$ne":"` + txIDBoost + `"}}}}`, []string{txIDAttest, txIDParity}},
+			{"no match", `{"v":3,"q":{"find":{"out.s2":"missing"}}}`, []string{}},
+			{"limit", `{"v":3,"q":{"find":{},"limit":2}}`, []string{txIDBoost, txIDAttest}},
+			{"skip", `{"v":3,"q":{"find":{},"skip":1,"limit":1}}`, []string{txIDAttest}},
+			{"sort", `{"v":3,"q":{"find":{},"sort":{"tx.h":-1}}}`, []string{txIDParity, txIDAttest, txIDBoost}},
+			{"sort keys", `{"v":3,"q":{"find":{},"sort":{"blk.i":-1,"tx.h":1}}}`, []string{txIDBoost, txIDAttest, txIDParity}},
+			{"sort skip limit", `{"v":3,"q":{"find":{},"sort":{"tx.h":1},"skip":1,"limit":1}}`, []string{txIDAttest}},
+		}
+	)
+
+	txs := testTxs(t)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := Parse([]byte(test.query))
+			require.NoError(t, err)
+			var docs []map[string]any
+			docs, err = q.Exec(txs)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, txIDs(docs))
+		})
+	}
+}
+
+// TestQuery_Run tests the method Run()
+func TestQuery_Run(t *testing.T) {
+	t.Parallel()
+
+	t.Run("stream", func(t *testing.T) {
+		q, err := Parse([]byte(`{"v":3,"q":{"find":{"out.tape.cell.s":"ATTEST"},"project":{"tx.h":1,"out.e.a":1}}}`))
+		require.NoError(t, err)
+		var docs []map[string]any
+		docs, err = q.Run(strings.NewReader(testNDJSON(t)))
+		require.NoError(t, err)
+		require.Equal(t, []string{txIDAttest, txIDParity}, txIDs(docs))
+		require.Equal(t, map[string]any{
+			"tx": map[string]any{"h": txIDAttest},
+			"out": []any{
+				map[string]any{"e": map[string]any{"a": "false"}},
+				map[string]any{"e": map[string]any{"a": "1LC16EQVsqVYGeYTCrjvNf8j28zr4DwBuk"}},
+			},
+		}, docs[0])
+	})
+
+	t.Run("stops at the limit", func(t *testing.T) {
+		q, err := Parse([]byte(`{"v":3,"q":{"find":{},"limit":1}}`))
+		require.NoError(t, err)
+		var docs []map[string]any
+		docs, err = q.Run(strings.NewReader(testNDJSON(t) + "invalid\n"))
+		require.NoError(t, err)
+		require.Equal(t, []string{txIDBoost}, txIDs(docs))
+	})
+
+	t.Run("invalid line", func(t *testing.T) {
+		q, err := Parse([]byte(`{"v":3,"q":{"find":{}}}`))
+		require.NoError(t, err)
+		_, err = q.Run(strings.NewReader(testNDJSON(t) + "invalid\n"))
+		require.Error(t, err)
+	})
+}
+
+// TestQuery_Project tests the project part of queries
+func TestQuery_Project(t *testing.T) {
+	t.Parallel()
+
+	txs := testTxs(t)
+
+	t.Run("include", func(t *testing.T) {
+		q, err := Parse([]byte(`{"v":3,"q":{"find":{"tx.h":"` + txIDBoost + `"},"project":{"tx.h":1,"out.s2":1,"blk":1}}}`))
+		require.NoError(t, err)
+		var docs []map[string]any
+		docs, err = q.Exec(txs)
+		require.NoError(t, err)
+		require.Equal(t, []map[string]any{{
+			"tx":  map[string]any{"h": txIDBoost},
+			"blk": map[string]any{"i": float64(635140), "t": float64(1589607858)},
+			"out": []any{
+				map[string]any{"s2": "一灯能除千年暗"},
+				map[string]any{"s2": docs[0]["out"].([]any)[1].(map[string]any)["s2"]},
+			},
+		}}, docs)
+	})
+
+	t.Run("exclude", func(t *testing.T) {
+		q, err := Parse([]byte(`{"v":3,"q":{"find":{"tx.h":"` + txIDBoost + `"},"project":{"in":0,"out":0,"blk":false}}}`))
+		require.NoError(t, err)
+		var docs []map[string]any
+		docs, err = q.Exec(txs)
+		require.NoError(t, err)
+		require.Len(t, docs, 1)
+		require.NotContains(t, docs[0], "in")
+		require.NotContains(t, docs[0], "out")
+		require.NotContains(t, docs[0], "blk")
+		require.Contains(t, docs[0], "tx")
+	})
+}
+
+// TestParse_Errors tests the queries Parse() rejects
+func TestParse_Errors(t *testing.T) {
+	t.Parallel()
+
+	var (
+		// Testing invalid and unsupported queries
+		tests = []struct {
+			name  string
+			query string
+		}{
+			{"invalid json", `{"v":3`},
+			{"version", `{"v":2,"q":{"find":{}}}`},
+			{"response transform", `{"v":3,"q":{"find":{}},"r":{"f":"[.[] | .tx.h]"}}`},
+			{"aggregate", `{"v":3,"q":{"aggregate":[{"$match":{}}]}}`},
+			{"negative limit", `{"v":3,"q":{"find":{},"limit":-1}}`},
+			{"negative skip", `{"v":3,"q":{"find":{},"skip":-1}}`},
+			{"sort direction", `{"v":3,"q":{"find":{},"sort":{"blk.i":2}}}`},
+			{"sort array", `{"v":3,"q":{"find":{},"sort":["blk.i"]}}`},
+			{"mixed project", `{"v":3,"q":{"find":{},"project":{"tx.h":1,"in":0}}}`},
+			{"project value", `{"v":3,"q":{"find":{},"project":{"tx.h":"yes"}}}`},
+			{"unsupported operator", `{"v":3,"q":{"find":{"$where":"true"}}}`},
+			{"unsupported field operator", `{"v":3,"q":{"find":{"tx.h":{"$size":1}}}}`},
+			{"invalid $or", `{"v":3,"q":{"find":{"$or":{}}}}`},
+			{"invalid $in", `{"v":3,"q":{"find":{"out.s2":{"$in":"ATTEST"}}}}`},
+			{"invalid $exists", `{"v":3,"q":{"find":{"out.s2":{"$exists":1}}}}`},
+			{"invalid $regex", `{"v":3,"q":{"find":{"out.s2":{"$regex":"("}}}}`},
+			{"$options without $regex", `{"v":3,"q":{"find":{"out.s2":{"$options":"i"}}}}`},
+		}
+	)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Parse([]byte(test.query))
+			require.Error(t, err)
+		})
+	}
+}
+
+// TestQuery_Match tests the method Match()
+func TestQuery_Match(t *testing.T) {
+	t.Parallel()
+
+	txs := testTxs(t)
+	q, err := Parse([]byte(`{"v":3,"q":{"find":{"out.s2":"ATTEST"}}}`))
+	require.NoError(t, err)
+
+	var match bool
+	match, err = q.Match(txs[2])
+	require.NoError(t, err)
+	require.True(t, match)
+
+	match, err = q.Match(txs[0])
+	require.NoError(t, err)
+	require.False(t, match)
+
+	_, err = q.Match(nil)
+	require.Error(t, err)
+}
+
+// ExampleQuery_Run example using Run()
+func ExampleQuery_Run() {
+	q, err := Parse([]byte(`{"v":3,"q":{"find":{"out.s3":"ATTEST"},"project":{"tx.h":1}}}`))
+	if err != nil {
+		fmt.Printf("error occurred: %s", err.Error())
+		return
+	}
+	ndjson := test.GetTestHex("../testing/bob/" + txIDAttest + ".json")
+	var tx *bob.Tx
+	if tx, err = bob.NewFromString(ndjson); err != nil {
+		fmt.Printf("error occurred: %s", err.Error())
+		return
+	}
+	var line []byte
+	if line, err = json.Marshal(tx); err != nil {
+		fmt.Printf("error occurred: %s", err.Error())
+		return
+	}
+
+	var docs []map[string]any
+	if docs, err = q.Run(strings.NewReader(string(line) + "\n")); err != nil {
+		fmt.Printf("error occurred: %s", err.Error())
+		return
+	}
+	fmt.Printf("found %d tx: %s", len(docs), docs[0]["tx"].(map[string]any)["h"])
+	// Output:found 1 tx: 26b754e6fdf04121b8d91160a0b252a22ae30204fc552605b7f6d3f08419f29e
+}
+
+// BenchmarkQuery_Exec benchmarks the method Exec()
+func BenchmarkQuery_Exec(b *testing.B) {
+	txs := testTxs(b)
+	q, _ := Parse([]byte(`{"v":3,"q":{"find":{"$or":[{"out.s2":"ATTEST"},{"out.s3":"ATTEST"}]},"project":{"tx.h":1}}}`))
+	for i := 0; i < b.N; i++ {
+		_, _ = q.Exec(txs)
+	}
+}
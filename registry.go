@@ -0,0 +1,185 @@
+package bob
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// TapeContext is the tape handed to a TapeDecoder, along with the tx and
+// the position it was found at (decoders like AIP need the tapes before it)
+type TapeContext struct {
+	Tx     *Tx
+	Output int
+	Tape   int
+}
+
+// CurrentTape returns the tape being decoded
+func (c *TapeContext) CurrentTape() *Tape {
+	return (*Tape)(&c.Tx.Out[c.Output].Tape[c.Tape])
+}
+
+// Tapes returns all tapes of the output being decoded
+func (c *TapeContext) Tapes() []*Tape {
+	tapes := make([]*Tape, len(c.Tx.Out[c.Output].Tape))
+	for i := range tapes {
+		tapes[i] = (*Tape)(&c.Tx.Out[c.Output].Tape[i])
+	}
+	return tapes
+}
+
+// TapeDecoder decodes a tape starting with the protocol prefix it was
+// registered for into a protocol specific value
+type TapeDecoder func(ctx *TapeContext) (any, error)
+
+// Registry maps protocol prefixes (Bitcom addresses such as
+// 19HxigV4QyBv3tHpQVcUEQyq1pzZVdoAut or literal strings) to the
+// decoders of their tapes
+//
+// A Registry is safe for concurrent use.
+type Registry struct {
+	mu       sync.RWMutex
+	decoders map[string]TapeDecoder
+}
+
+// NewRegistry creates a new empty Registry
+func NewRegistry() *Registry {
+	return &Registry{decoders: make(map[string]TapeDecoder)}
+}
+
+// Register adds the decoder for tapes starting with the given prefix
+//
+// Only one decoder can be registered per prefix.
+func (r *Registry) Register(prefix string, decoder TapeDecoder) error {
+	if prefix == "" {
+		return fmt.Errorf("prefix must be set")
+	}
+	if decoder == nil {
+		return fmt.Errorf("decoder for %s must be set", prefix)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.decoders[prefix]; ok {
+		return fmt.Errorf("a decoder for %s is already registered", prefix)
+	}
+	r.decoders[prefix] = decoder
+	return nil
+}
+
+// Lookup returns the decoder registered for the prefix
+func (r *Registry) Lookup(prefix string) (TapeDecoder, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	decoder, ok := r.decoders[prefix]
+	return decoder, ok
+}
+
+// Prefixes returns the registered prefixes, sorted
+func (r *Registry) Prefixes() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	prefixes := make([]string, 0, len(r.decoders))
+	for prefix := range r.decoders {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+	return prefixes
+}
+
+// TapeError is reported for a tape that matched a registered prefix
+// but failed to decode
+type TapeError struct {
+	Output int
+	Tape   int
+	Prefix string
+	Err    error
+}
+
+// Error returns the error message including the position of the tape
+func (e *TapeError) Error() string {
+	return fmt.Sprintf("output %d tape %d (%s): %v", e.Output, e.Tape, e.Prefix, e.Err)
+}
+
+// Unwrap returns the underlying decoder error
+func (e *TapeError) Unwrap() error {
+	return e.Err
+}
+
+// DecodedTape is the result of decoding a single tape, either
+// Value or Err is set
+type DecodedTape struct {
+	Output int
+	Tape   int
+	Prefix string
+	Value  any
+	Err    *TapeError
+}
+
+// DecodedTapes are the results of decoding the tapes of a tx, in output and tape order
+type DecodedTapes []DecodedTape
+
+// Errors returns the errors of the tapes that failed to decode
+func (d DecodedTapes) Errors() []error {
+	var errs []error
+	for i := range d {
+		if d[i].Err != nil {
+			errs = append(errs, d[i].Err)
+		}
+	}
+	return errs
+}
+
+// ByPrefix returns the results of the tapes with the given prefix
+func (d DecodedTapes) ByPrefix(prefix string) DecodedTapes {
+	var tapes DecodedTapes
+	for i := range d {
+		if d[i].Prefix == prefix {
+			tapes = append(tapes, d[i])
+		}
+	}
+	return tapes
+}
+
+// ValuesOf returns the successfully decoded values of type T
+func ValuesOf[T any](d DecodedTapes) []T {
+	var values []T
+	for i := range d {
+		if v, ok := d[i].Value.(T); ok && d[i].Err == nil {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// Decode runs the registered decoders on every output tape whose first
+// cell is a registered prefix
+//
+// Tapes without a registered prefix are left out. Tapes that matched a
+// prefix but failed to decode are included with Err set.
+func (t *Tx) Decode(reg *Registry) DecodedTapes {
+	var results DecodedTapes
+	if reg == nil {
+		return results
+	}
+	for i := range t.Out {
+		for j := range t.Out[i].Tape {
+			prefix, err := (*Tape)(&t.Out[i].Tape[j]).Prefix()
+			if err != nil {
+				continue
+			}
+			decoder, ok := reg.Lookup(prefix)
+			if !ok {
+				continue
+			}
+
+			result := DecodedTape{Output: i, Tape: j, Prefix: prefix}
+			if result.Value, err = decoder(&TapeContext{Tx: t, Output: i, Tape: j}); err != nil {
+				result.Value = nil
+				result.Err = &TapeError{Output: i, Tape: j, Prefix: prefix, Err: err}
+			}
+			results = append(results, result)
+		}
+	}
+	return results
+}
@@ -0,0 +1,156 @@
+package bob
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testBPrefix   = "19HxigV4QyBv3tHpQVcUEQyq1pzZVdoAut"
+	testMapPrefix = "1PuQa7K62MiKCtssSLKy1kh56WWU7MtUR5"
+	testAIPPrefix = "15PciHG22SNLQJXMoSUaWVi7WSqc7hCfva"
+)
+
+// testMediaType is a decoded value used in the registry tests
+type testMediaType string
+
+// testMediaTypeDecoder decodes the media type of a B:// tape
+func testMediaTypeDecoder(ctx *TapeContext) (any, error) {
+	c, err := ctx.CurrentTape().CellAt(2)
+	if err != nil {
+		return nil, err
+	}
+	var s string
+	if s, err = c.String(); err != nil {
+		return nil, err
+	}
+	return testMediaType(s), nil
+}
+
+// TestRegistry_Register tests the methods Register(), Lookup() and Prefixes()
+func TestRegistry_Register(t *testing.T) {
+	t.Parallel()
+
+	reg := NewRegistry()
+	require.Empty(t, reg.Prefixes())
+
+	require.NoError(t, reg.Register(testMapPrefix, testMediaTypeDecoder))
+	require.NoError(t, reg.Register(testBPrefix, testMediaTypeDecoder))
+	require.Error(t, reg.Register(testBPrefix, testMediaTypeDecoder))
+	require.Error(t, reg.Register("", testMediaTypeDecoder))
+	require.Error(t, reg.Register("literal", nil))
+
+	require.Equal(t, []string{testBPrefix, testMapPrefix}, reg.Prefixes())
+
+	_, ok := reg.Lookup(testBPrefix)
+	require.True(t, ok)
+	_, ok = reg.Lookup(testAIPPrefix)
+	require.False(t, ok)
+}
+
+// TestRegistry_Concurrent tests registering and decoding from several goroutines
+func TestRegistry_Concurrent(t *testing.T) {
+	t.Parallel()
+
+	bobTx, err := NewFromRawTxString(rawBobTx)
+	require.NoError(t, err)
+
+	reg := NewRegistry()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			_ = reg.Register(fmt.Sprintf("prefix-%d", i), testMediaTypeDecoder)
+		}(i)
+		go func() {
+			defer wg.Done()
+			_ = bobTx.Decode(reg)
+		}()
+	}
+	wg.Wait()
+	require.Len(t, reg.Prefixes(), 10)
+}
+
+// TestTx_Decode tests the method Decode()
+func TestTx_Decode(t *testing.T) {
+	t.Parallel()
+
+	bobTx, err := NewFromRawTxString(rawBobTx)
+	require.NoError(t, err)
+
+	errFailed := errors.New("failed")
+	reg := NewRegistry()
+	require.NoError(t, reg.Register(testBPrefix, testMediaTypeDecoder))
+	require.NoError(t, reg.Register(testAIPPrefix, func(ctx *TapeContext) (any, error) {
+		// the tapes before the AIP tape are available
+		require.Len(t, ctx.Tapes(), 4)
+		require.Equal(t, 3, ctx.Tape)
+		return nil, errFailed
+	}))
+
+	results := bobTx.Decode(reg)
+	require.Len(t, results, 2)
+
+	require.Equal(t, 0, results[0].Output)
+	require.Equal(t, 1, results[0].Tape)
+	require.Equal(t, testBPrefix, results[0].Prefix)
+	require.Nil(t, results[0].Err)
+	require.Equal(t, testMediaType("text/plain"), results[0].Value)
+
+	require.Equal(t, 3, results[1].Tape)
+	require.Nil(t, results[1].Value)
+	require.ErrorIs(t, results[1].Err, errFailed)
+	require.Equal(t, "output 0 tape 3 ("+testAIPPrefix+"): failed", results[1].Err.Error())
+
+	errs := results.Errors()
+	require.Len(t, errs, 1)
+	var tapeErr *TapeError
+	require.ErrorAs(t, errs[0], &tapeErr)
+	require.Equal(t, testAIPPrefix, tapeErr.Prefix)
+
+	require.Len(t, results.ByPrefix(testBPrefix), 1)
+	require.Empty(t, results.ByPrefix(testMapPrefix))
+	require.Equal(t, []testMediaType{"text/plain"}, ValuesOf[testMediaType](results))
+	require.Empty(t, ValuesOf[string](results))
+
+	// nothing registered
+	require.Empty(t, bobTx.Decode(NewRegistry()))
+	require.Empty(t, bobTx.Decode(nil))
+}
+
+// ExampleTx_Decode example using Decode() with a Registry
+func ExampleTx_Decode() {
+	b, err := NewFromRawTxString(rawBobTx)
+	if err != nil {
+		fmt.Printf("error occurred: %s", err.Error())
+		return
+	}
+
+	reg := NewRegistry()
+	if err = reg.Register("19HxigV4QyBv3tHpQVcUEQyq1pzZVdoAut", func(ctx *TapeContext) (any, error) {
+		return len(ctx.CurrentTape().Cell), nil
+	}); err != nil {
+		fmt.Printf("error occurred: %s", err.Error())
+		return
+	}
+
+	for _, result := range b.Decode(reg) {
+		fmt.Printf("found B:// tape with %d cells", result.Value)
+	}
+	// Output:found B:// tape with 5 cells
+}
+
+// BenchmarkTx_Decode benchmarks the method Decode()
+func BenchmarkTx_Decode(b *testing.B) {
+	bobTx, _ := NewFromRawTxString(rawBobTx)
+	reg := NewRegistry()
+	_ = reg.Register(testBPrefix, testMediaTypeDecoder)
+	for i := 0; i < b.N; i++ {
+		_ = bobTx.Decode(reg)
+	}
+}
@@ -0,0 +1,408 @@
+package bob
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/bitcoinschema/go-bpu"
+	"github.com/bsv-blockchain/go-sdk/script"
+	"github.com/bsv-blockchain/go-sdk/transaction"
+)
+
+// shallowChunks is the number of chunks kept at each end of a script
+// with more than 255 chunks parsed in shallow mode
+const shallowChunks = 128
+
+// scriptInfo is what the original script of some tapes tells about them
+// that the tapes do not (see ToTxOptions.Original)
+//
+// Cells are numbered across the tapes of the script, so the info still
+// applies after tokens are moved between tapes (see Parser).
+type scriptInfo struct {
+	pushOps map[int]uint8  // push opcodes of pushdatas not pushed with the opcode implied by their length
+	leftOut map[int][]byte // raw chunks left out before a cell (split tokens excluded, chunks dropped by shallow parsing)
+}
+
+// newScriptInfo matches the cells of the tapes with the chunks of their
+// original script by the cell index (II), the same way bpu split them
+//
+// Returns nil if there is no original script or the tapes do not match
+// it: a cell differs from the chunk at its index, or chunks are left out
+// where neither a split token nor shallow parsing could have left them out.
+func newScriptInfo(tapes []bpu.Tape, s *script.Script) *scriptInfo {
+	if s == nil {
+		return nil
+	}
+	chunks, err := script.DecodeScript(*s, script.DecodeOptionsParseOpReturn)
+	if err != nil || !bytes.Equal(chunksBytes(chunks), *s) {
+		return nil
+	}
+	if info := matchChunks(tapes, chunks, false); info != nil {
+		return info
+	}
+	if len(chunks) > 255 {
+		return matchChunks(tapes, chunks, true)
+	}
+	return nil
+}
+
+// matchChunks matches the cells of the tapes with the chunks bpu parsed,
+// which are only the first and last 128 chunks of a large script parsed
+// in shallow mode
+func matchChunks(tapes []bpu.Tape, chunks []*script.ScriptChunk, shallow bool) *scriptInfo {
+	kept := make([]int, 0, len(chunks))
+	for k := range chunks {
+		if !shallow || k < shallowChunks || k >= len(chunks)-shallowChunks {
+			kept = append(kept, k)
+		}
+	}
+
+	info := &scriptInfo{pushOps: map[int]uint8{}, leftOut: map[int][]byte{}}
+	k, next, n := 0, 0, 0
+	for tapeIdx := range tapes {
+		for cellIdx := range tapes[tapeIdx].Cell {
+			cell := &tapes[tapeIdx].Cell[cellIdx]
+			for k < len(kept) && uint8(k) != cell.II {
+				k++
+			}
+			if k == len(kept) {
+				return nil
+			}
+			chunk := chunks[kept[k]]
+			if !cellMatches(cell, chunk) {
+				return nil
+			}
+			if kept[k] > next {
+				// split tokens are only left out between tapes
+				if cellIdx > 0 && !(shallow && k == shallowChunks) {
+					return nil
+				}
+				info.leftOut[n] = chunksBytes(chunks[next:kept[k]])
+			}
+			if !isOpcode(chunk.Op) && chunk.Op != pushOp(len(chunk.Data)) {
+				info.pushOps[n] = chunk.Op
+			}
+			next = kept[k] + 1
+			k++
+			n++
+		}
+	}
+	if next < len(chunks) {
+		info.leftOut[n] = chunksBytes(chunks[next:])
+	}
+	return info
+}
+
+// cellMatches returns true if the cell holds the opcode or data of the chunk
+func cellMatches(cell *bpu.Cell, chunk *script.ScriptChunk) bool {
+	op, ok := cellOpcode(cell)
+	if isOpcode(chunk.Op) {
+		return ok && op == chunk.Op
+	}
+	if ok {
+		return false
+	}
+	data, err := cellBytes(cell)
+	return err == nil && bytes.Equal(data, chunk.Data)
+}
+
+// inputScript returns the unlocking script of the input of the tx, if known
+func inputScript(tx *transaction.Transaction, i int) *script.Script {
+	if tx == nil || i >= len(tx.Inputs) {
+		return nil
+	}
+	return tx.Inputs[i].UnlockingScript
+}
+
+// outputScript returns the locking script of the output of the tx, if known
+func outputScript(tx *transaction.Transaction, i int) *script.Script {
+	if tx == nil || i >= len(tx.Outputs) {
+		return nil
+	}
+	return tx.Outputs[i].LockingScript
+}
+
+// chunksBytes encodes the chunks back into script bytes
+func chunksBytes(chunks []*script.ScriptChunk) []byte {
+	s := make(script.Script, 0)
+	for _, chunk := range chunks {
+		if isOpcode(chunk.Op) {
+			s = append(s, chunk.Op)
+			continue
+		}
+		if err := appendPushData(&s, chunk.Op, chunk.Data); err != nil {
+			return nil
+		}
+	}
+	return s
+}
+
+// isOpcode returns true if the opcode does not push data
+func isOpcode(op uint8) bool {
+	return op == script.Op0 || op > script.OpPUSHDATA4
+}
+
+// tapesToScript rebuilds a script from its tapes
+//
+// The chunks left out of the tapes (split tokens excluded by the split
+// rules, chunks dropped by shallow parsing) are restored from the info
+// of the original script when it is known. Otherwise the gaps in the cell
+// indexes (II) show where they were: a single chunk left out between two
+// tapes after an OP_RETURN is assumed to be the protocol delimiter "|",
+// any other gap is an error. The assumptions made are returned.
+func tapesToScript(tapes []bpu.Tape, info *scriptInfo) (*script.Script, []string, error) {
+	if info == nil {
+		if err := checkTruncated(tapes); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var (
+		s           = make(script.Script, 0)
+		assumptions []string
+		prev        *bpu.Cell
+		opReturn    bool
+		n           int
+	)
+	for tapeIdx := range tapes {
+		for cellIdx := range tapes[tapeIdx].Cell {
+			cell := &tapes[tapeIdx].Cell[cellIdx]
+			var pushOp *uint8
+			if info != nil {
+				s = append(s, info.leftOut[n]...)
+				if op, ok := info.pushOps[n]; ok {
+					pushOp = &op
+				}
+			} else {
+				assumption, err := leftOut(&s, prev, cell, tapeIdx, cellIdx, opReturn)
+				if err != nil {
+					return nil, nil, fmt.Errorf("tape %d cell %d: %w", tapeIdx, cellIdx, err)
+				}
+				if assumption != "" {
+					assumptions = append(assumptions, fmt.Sprintf("tape %d cell %d: %s", tapeIdx, cellIdx, assumption))
+				}
+			}
+			if err := appendCell(&s, cell, pushOp); err != nil {
+				return nil, nil, fmt.Errorf("tape %d cell %d: %w", tapeIdx, cellIdx, err)
+			}
+			if op, ok := cellOpcode(cell); ok && op == script.OpRETURN {
+				opReturn = true
+			}
+			prev = cell
+			n++
+		}
+	}
+	if info != nil {
+		s = append(s, info.leftOut[n]...)
+	}
+	return &s, assumptions, nil
+}
+
+// leftOut restores the chunk left out before the cell, as far as the cell
+// indexes (II) tell, returning the assumption made if any
+//
+// When the cell indexes are not usable (all the same, as in documents
+// without them), every tape after the first data tape is assumed to
+// start after a protocol delimiter.
+func leftOut(s *script.Script, prev, cell *bpu.Cell, tapeIdx, cellIdx int, opReturn bool) (string, error) {
+	gap := int(cell.II)
+	if prev != nil {
+		gap = int(cell.II-prev.II) - 1
+	}
+	switch {
+	case gap == 0:
+		return "", nil
+	case gap == -1 && prev != nil:
+		if delimiterAssumed(tapeIdx, cellIdx) {
+			*s = append(*s, script.OpDATA1, ProtocolDelimiterByte)
+			return "the cell indexes are not set, a protocol delimiter \"|\" is assumed before it", nil
+		}
+		return "", nil
+	case gap == 1 && cellIdx == 0 && opReturn:
+		*s = append(*s, script.OpDATA1, ProtocolDelimiterByte)
+		return "the chunk left out before it is assumed to be the protocol delimiter \"|\"", nil
+	}
+	return "", fmt.Errorf("%d chunks were left out of the tapes before it, they cannot be rebuilt", gap)
+}
+
+// cellIndexesSet returns false if the cell indexes (II) of the tapes are
+// not usable: two cells in a row have the same index, as in documents
+// without them
+func cellIndexesSet(tapes []bpu.Tape) bool {
+	var prev *bpu.Cell
+	for tapeIdx := range tapes {
+		for cellIdx := range tapes[tapeIdx].Cell {
+			cell := &tapes[tapeIdx].Cell[cellIdx]
+			if prev != nil && cell.II == prev.II {
+				return false
+			}
+			prev = cell
+		}
+	}
+	return true
+}
+
+// delimiterAssumed returns true if a protocol delimiter is assumed to be
+// left out before the cell when the cell indexes (II) are not set: every
+// tape after the first data tape starts after one
+func delimiterAssumed(tapeIdx, cellIdx int) bool {
+	return cellIdx == 0 && tapeIdx > 1
+}
+
+// checkTruncated returns an error if the tapes look like those of a script
+// with more than 255 chunks parsed in shallow mode, which only keeps the
+// first and last 128 chunks
+//
+// The cell indexes (II) of such tapes cover exactly 256 chunks, the
+// chunks dropped between the cells at index 127 and 128 leave no gap.
+func checkTruncated(tapes []bpu.Tape) error {
+	var (
+		prev      *bpu.Cell
+		positions int
+		tapeAt    = -1
+		cellAt    int
+	)
+	for tapeIdx := range tapes {
+		for cellIdx := range tapes[tapeIdx].Cell {
+			cell := &tapes[tapeIdx].Cell[cellIdx]
+			if prev == nil {
+				positions = int(cell.II)
+			} else {
+				diff := int(cell.II - prev.II)
+				if diff == 0 {
+					// the cell indexes are not set
+					return nil
+				}
+				positions += diff
+			}
+			if positions == shallowChunks && tapeAt < 0 {
+				tapeAt, cellAt = tapeIdx, cellIdx
+			}
+			prev = cell
+		}
+	}
+	if prev == nil || positions+1 != 2*shallowChunks || tapeAt < 0 {
+		return nil
+	}
+	return fmt.Errorf("tape %d cell %d: the chunks before it were probably dropped by shallow parsing (see ModeDeep), the script cannot be rebuilt", tapeAt, cellAt)
+}
+
+// appendCell appends the opcode or pushdata of a cell to the script
+//
+// Pushdatas use the given push opcode if it can push their data, then the
+// push opcode set on the cell, then the one implied by the length of the data
+func appendCell(s *script.Script, cell *bpu.Cell, op *uint8) error {
+	if op, ok := cellOpcode(cell); ok {
+		*s = append(*s, op)
+		return nil
+	}
+
+	data, err := cellBytes(cell)
+	if err != nil {
+		return err
+	}
+	switch {
+	case op != nil && canPush(*op, len(data)):
+		return appendPushData(s, *op, data)
+	case cell.Op != nil:
+		return appendPushData(s, *cell.Op, data)
+	}
+	return appendPushData(s, pushOp(len(data)), data)
+}
+
+// appendPushData appends the data to the script using the given push opcode
+func appendPushData(s *script.Script, op uint8, data []byte) error {
+	l := len(data)
+	if !canPush(op, l) {
+		return fmt.Errorf("%s cannot push %d bytes", script.OpCodeValues[op], l)
+	}
+	switch op {
+	case script.OpPUSHDATA1:
+		*s = append(*s, op, byte(l))
+	case script.OpPUSHDATA2:
+		*s = append(*s, op)
+		*s = binary.LittleEndian.AppendUint16(*s, uint16(l))
+	case script.OpPUSHDATA4:
+		*s = append(*s, op)
+		*s = binary.LittleEndian.AppendUint32(*s, uint32(l))
+	default:
+		*s = append(*s, op)
+	}
+	*s = append(*s, data...)
+	return nil
+}
+
+// canPush returns true if the push opcode can push l bytes
+func canPush(op uint8, l int) bool {
+	switch op {
+	case script.OpPUSHDATA1:
+		return l <= 0xff
+	case script.OpPUSHDATA2:
+		return l <= 0xffff
+	case script.OpPUSHDATA4:
+		return uint64(l) <= 0xffffffff
+	}
+	return op < script.OpPUSHDATA1 && int(op) == l
+}
+
+// pushOp returns the push opcode implied by the length of the data
+func pushOp(l int) uint8 {
+	switch {
+	case l < int(script.OpPUSHDATA1):
+		return uint8(l)
+	case l <= 0xff:
+		return script.OpPUSHDATA1
+	case l <= 0xffff:
+		return script.OpPUSHDATA2
+	default:
+		return script.OpPUSHDATA4
+	}
+}
+
+// cellOpcode returns the opcode of a cell that is not a pushdata
+//
+// Pushdata cells parsed by bpu carry no Op, but documents may set their push opcode
+func cellOpcode(cell *bpu.Cell) (uint8, bool) {
+	if cell.Op != nil {
+		if isOpcode(*cell.Op) {
+			return *cell.Op, true
+		}
+		return 0, false
+	}
+	if cell.Ops != nil {
+		op, ok := script.OpCodeStrings[*cell.Ops]
+		return op, ok && isOpcode(op)
+	}
+	return 0, false
+}
+
+// cellBytes returns the data of a pushdata cell from whichever of H, B or S
+// is set (or LB and LS, used by indexers for large pushdatas)
+func cellBytes(cell *bpu.Cell) ([]byte, error) {
+	switch {
+	case cell.H != nil:
+		data, err := hex.DecodeString(*cell.H)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hex %q: %w", *cell.H, err)
+		}
+		return data, nil
+	case cell.B != nil || cell.LB != nil:
+		b := cell.B
+		if b == nil {
+			b = cell.LB
+		}
+		data, err := base64.StdEncoding.DecodeString(*b)
+		if err != nil {
+			return nil, fmt.Errorf("invalid base64 %q: %w", *b, err)
+		}
+		return data, nil
+	case cell.S != nil:
+		return []byte(*cell.S), nil
+	case cell.LS != nil:
+		return []byte(*cell.LS), nil
+	}
+	return nil, fmt.Errorf("cell has no data")
+}
@@ -0,0 +1,500 @@
+package bob
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/bitcoinschema/go-bpu"
+	"github.com/bsv-blockchain/go-sdk/script"
+	"github.com/bsv-blockchain/go-sdk/transaction"
+	"github.com/stretchr/testify/require"
+)
+
+// testNonMinimalPushesTx returns a tx with outputs using non-minimal push encodings
+func testNonMinimalPushesTx() *transaction.Transaction {
+	tx := transaction.NewTransaction()
+
+	// OP_FALSE OP_RETURN <OP_PUSHDATA1 5 bytes> | <OP_PUSHDATA2 3 bytes> <OP_PUSHDATA1 empty> OP_0 <01 05> OP_5 <OP_PUSHDATA4 2 bytes>
+	s := script.NewFromBytes([]byte{script.OpFALSE, script.OpRETURN})
+	*s = append(*s, script.OpPUSHDATA1, 5)
+	*s = append(*s, []byte("hello")...)
+	*s = append(*s, script.OpDATA1, ProtocolDelimiterByte)
+	*s = append(*s, script.OpPUSHDATA2, 3, 0)
+	*s = append(*s, []byte("abc")...)
+	*s = append(*s, script.OpPUSHDATA1, 0)
+	*s = append(*s, script.Op0)
+	*s = append(*s, script.OpDATA1, 0x05)
+	*s = append(*s, script.Op5)
+	*s = append(*s, script.OpPUSHDATA4, 2, 0, 0, 0, 0x13, 0x37)
+	tx.AddOutput(&transaction.TransactionOutput{LockingScript: s})
+
+	// a large minimal push followed by a non-minimal one
+	large := script.NewFromBytes([]byte{script.OpFALSE, script.OpRETURN})
+	_ = large.AppendPushData(bytes.Repeat([]byte{0xab}, 300))
+	*large = append(*large, script.OpPUSHDATA2, 76, 0)
+	*large = append(*large, bytes.Repeat([]byte{0xcd}, 76)...)
+	tx.AddOutput(&transaction.TransactionOutput{LockingScript: large, Satoshis: 1})
+
+	return tx
+}
+
+// TestTx_ToTx_ByteExact tests that push encodings are preserved byte for byte with the original tx
+func TestTx_ToTx_ByteExact(t *testing.T) {
+	t.Parallel()
+
+	tx := testNonMinimalPushesTx()
+
+	var (
+		// Testing all parse entry points
+		tests = []struct {
+			name  string
+			parse func() (*Tx, error)
+		}{
+			{"from raw tx", func() (*Tx, error) { return NewFromRawTxString(tx.String()) }},
+			{"from tx", func() (*Tx, error) { return NewFromTx(tx) }},
+			{"deep mode", func() (*Tx, error) { return NewFromTxWithOptions(tx, ParseOptions{Mode: ModeDeep}) }},
+		}
+	)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			bobTx, err := test.parse()
+			require.NoError(t, err)
+
+			var rebuilt *transaction.Transaction
+			rebuilt, err = bobTx.ToTxWithOptions(ToTxOptions{Original: tx})
+			require.NoError(t, err)
+			require.Equal(t, tx.String(), rebuilt.String())
+
+			// without it only the tapes are used
+			rebuilt, err = bobTx.ToTx()
+			require.NoError(t, err)
+			require.Equal(t, "006a0568656c6c6f017c036162630000010555021337", rebuilt.Outputs[0].LockingScript.String())
+		})
+	}
+}
+
+// TestTx_ToTx_ThroughJSON tests that push opcodes are not part of the BOB format
+func TestTx_ToTx_ThroughJSON(t *testing.T) {
+	t.Parallel()
+
+	tx := testNonMinimalPushesTx()
+	bobTx, err := NewFromTx(tx)
+	require.NoError(t, err)
+
+	var str string
+	str, err = bobTx.ToString()
+	require.NoError(t, err)
+	require.NotContains(t, str, `"ops":"OP_PUSHDATA`)
+
+	// the scripts are rebuilt with minimal pushes
+	var decoded *Tx
+	decoded, err = NewFromString(str)
+	require.NoError(t, err)
+
+	var rebuilt *transaction.Transaction
+	rebuilt, err = decoded.ToTx()
+	require.NoError(t, err)
+	require.Equal(t, "006a0568656c6c6f017c036162630000010555021337", rebuilt.Outputs[0].LockingScript.String())
+
+	// the original tx applies to documents as well
+	rebuilt, err = decoded.ToTxWithOptions(ToTxOptions{Original: tx})
+	require.NoError(t, err)
+	require.Equal(t, tx.String(), rebuilt.String())
+}
+
+// TestScriptInfo tests what the original scripts tell about the cells
+func TestScriptInfo(t *testing.T) {
+	t.Parallel()
+
+	tx := testNonMinimalPushesTx()
+	bobTx, err := NewFromTx(tx)
+	require.NoError(t, err)
+
+	tapes := bobTx.Out[0].Tape
+	require.Len(t, tapes, 4)
+
+	// cells are numbered across the tapes: OP_FALSE OP_RETURN | hello | abc <empty> OP_0 | 05 OP_5 1337
+	info := newScriptInfo(tapes, outputScript(tx, 0))
+	require.NotNil(t, info)
+	require.Equal(t, map[int]uint8{
+		2: script.OpPUSHDATA1, // OP_PUSHDATA1 for 5 bytes
+		3: script.OpPUSHDATA2, // OP_PUSHDATA2 for 3 bytes
+		4: script.OpPUSHDATA1, // empty push, not an OP_0
+		8: script.OpPUSHDATA4,
+	}, info.pushOps)
+	require.Equal(t, map[int][]byte{3: {script.OpDATA1, ProtocolDelimiterByte}}, info.leftOut)
+
+	// pushdata cells carry no opcode
+	require.Equal(t, "hello", *tapes[1].Cell[0].S)
+	require.Nil(t, tapes[1].Cell[0].Op)
+	require.Nil(t, tapes[1].Cell[0].Ops)
+	require.Nil(t, tapes[2].Cell[0].Op)
+	require.Nil(t, tapes[2].Cell[1].Op)
+
+	// the OP_0 ends the tape, a direct push of 0x05 is not turned into OP_5
+	require.Equal(t, script.Op0, *tapes[2].Cell[2].Op)
+	require.Nil(t, tapes[3].Cell[0].Op)
+	require.Equal(t, "05", *tapes[3].Cell[0].H)
+	require.Equal(t, script.Op5, *tapes[3].Cell[1].Op)
+
+	// minimal pushes are left alone
+	require.Equal(t, map[int]uint8{3: script.OpPUSHDATA2}, newScriptInfo(bobTx.Out[1].Tape, outputScript(tx, 1)).pushOps)
+
+	// unknown scripts have no info
+	require.Nil(t, newScriptInfo(tapes, outputScript(tx, 2)))
+	require.Nil(t, newScriptInfo(tapes, outputScript(nil, 0)))
+	require.Nil(t, newScriptInfo(tapes, inputScript(tx, 0)))
+
+	// nor do scripts the cells do not match
+	require.Nil(t, newScriptInfo(tapes, outputScript(tx, 1)))
+	h := "00"
+	tapes[1].Cell[0].S, tapes[1].Cell[0].B, tapes[1].Cell[0].H = nil, nil, &h
+	require.Nil(t, newScriptInfo(tapes, outputScript(tx, 0)))
+}
+
+// TestTx_ToTx_LeftOutChunks tests rebuilding the chunks left out of the tapes
+func TestTx_ToTx_LeftOutChunks(t *testing.T) {
+	t.Parallel()
+
+	pushes := []byte{script.OpFALSE, script.OpRETURN}
+	for i := 0; i < 300; i++ {
+		pushes = append(pushes, script.OpDATA2, byte(i), byte(i>>8))
+	}
+
+	var (
+		// Testing excluded tokens and truncated scripts
+		tests = []struct {
+			name          string
+			script        string
+			rule          *SplitRule
+			expectedTapes int
+			expectedJSON  string
+		}{
+			{
+				"protocol delimiter",
+				"006a0568656c6c6f017c03616263",
+				nil, 3, "006a0568656c6c6f017c03616263",
+			},
+			{
+				"OP_SWAP",
+				"006a0568656c6c6f7c03616263",
+				nil, 3, "006a0568656c6c6f017c03616263",
+			},
+			{
+				"OP_CODESEPARATOR",
+				"006a0568656c6c6fab03616263",
+				testRule(NewOpSplitRule(script.OpCODESEPARATOR, SplitExclude)), 3, "006a0568656c6c6f017c03616263",
+			},
+			{
+				"MARK",
+				"006a0568656c6c6f044d41524b03616263",
+				testRule(NewStringSplitRule("MARK", SplitExclude)), 3, "006a0568656c6c6f017c03616263",
+			},
+			{
+				"include right",
+				"006a0568656c6c6f044d41524b03616263",
+				testRule(NewStringSplitRule("MARK", SplitIncludeRight)), 3, "006a0568656c6c6f044d41524b03616263",
+			},
+			{
+				"delimiter at the end",
+				"006a0568656c6c6f017c",
+				nil, 2, "006a0568656c6c6f",
+			},
+			{
+				"shallow truncation",
+				hex.EncodeToString(pushes),
+				nil, 2, "",
+			},
+		}
+	)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s, err := script.NewFromHex(test.script)
+			require.NoError(t, err)
+			tx := transaction.NewTransaction()
+			tx.AddOutput(&transaction.TransactionOutput{LockingScript: s, Satoshis: 1})
+
+			p := NewParser()
+			if test.rule != nil {
+				require.NoError(t, p.AddSplitRule(*test.rule))
+			}
+			var bobTx *Tx
+			bobTx, err = p.FromTx(tx)
+			require.NoError(t, err)
+			require.Len(t, bobTx.Out[0].Tape, test.expectedTapes)
+
+			// the original tx restores the chunks left out
+			var rebuilt *transaction.Transaction
+			rebuilt, err = bobTx.ToTxWithOptions(ToTxOptions{Original: tx})
+			require.NoError(t, err)
+			require.Equal(t, tx.String(), rebuilt.String())
+
+			// without it the tapes only tell what the cell indexes do
+			rebuilt, err = bobTx.ToTx()
+			if test.expectedJSON == "" {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, test.expectedJSON, rebuilt.Outputs[0].LockingScript.String())
+			}
+
+			// and so do documents
+			var str string
+			str, err = bobTx.ToString()
+			require.NoError(t, err)
+			var decoded *Tx
+			decoded, err = NewFromString(str)
+			require.NoError(t, err)
+			rebuilt, err = decoded.ToTx()
+			if test.expectedJSON == "" {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expectedJSON, rebuilt.Outputs[0].LockingScript.String())
+		})
+	}
+}
+
+// TestTx_ToTx_Truncated tests the error for a document of a truncated script
+func TestTx_ToTx_Truncated(t *testing.T) {
+	t.Parallel()
+
+	s := script.NewFromBytes([]byte{script.OpFALSE, script.OpRETURN})
+	for i := 0; i < 300; i++ {
+		*s = append(*s, script.OpDATA2, byte(i), byte(i>>8))
+	}
+	tx := transaction.NewTransaction()
+	tx.AddOutput(&transaction.TransactionOutput{LockingScript: script.NewFromBytes([]byte{script.OpTRUE}), Satoshis: 1})
+	tx.AddOutput(&transaction.TransactionOutput{LockingScript: s, Satoshis: 1})
+
+	bobTx, err := NewFromTx(tx)
+	require.NoError(t, err)
+	var str string
+	str, err = bobTx.ToString()
+	require.NoError(t, err)
+
+	// the cell at index 128 holds the data of chunk 174 (pushed 172)
+	var decoded *Tx
+	decoded, err = NewFromString(str)
+	require.NoError(t, err)
+	require.Equal(t, "ac00", *decoded.Out[1].Tape[1].Cell[126].H)
+
+	_, err = decoded.ToTx()
+	require.EqualError(t, err, "failed to rebuild locking script of output 1: tape 1 cell 126: "+
+		"the chunks before it were probably dropped by shallow parsing (see ModeDeep), the script cannot be rebuilt")
+
+	// a deep parse keeps all the chunks
+	bobTx, err = NewFromTxWithOptions(tx, ParseOptions{Mode: ModeDeep})
+	require.NoError(t, err)
+	str, err = bobTx.ToString()
+	require.NoError(t, err)
+	decoded, err = NewFromString(str)
+	require.NoError(t, err)
+
+	var rebuilt *transaction.Transaction
+	rebuilt, err = decoded.ToTx()
+	require.NoError(t, err)
+	require.Equal(t, tx.String(), rebuilt.String())
+}
+
+// TestTx_ToTx_EditedTapes tests rebuilding tapes edited after parsing
+func TestTx_ToTx_EditedTapes(t *testing.T) {
+	t.Parallel()
+
+	// OP_FALSE OP_RETURN a | OP_PUSHDATA1 b
+	s, err := script.NewFromHex("006a0161017c4c0162")
+	require.NoError(t, err)
+	tx := transaction.NewTransaction()
+	tx.AddOutput(&transaction.TransactionOutput{LockingScript: s, Satoshis: 1})
+
+	var (
+		// Testing edits of the parsed tapes
+		tests = []struct {
+			name             string
+			modify           func(b *Tx)
+			expectedScript   string
+			expectedOriginal string
+			expectedError    string
+		}{
+			{
+				"no edit",
+				func(*Tx) {},
+				"006a0161017c0162",
+				"006a0161017c4c0162",
+				"",
+			},
+			{
+				"cell moved to the previous tape",
+				func(b *Tx) {
+					tapes := b.Out[0].Tape
+					tapes[1].Cell = append(tapes[1].Cell, tapes[2].Cell...)
+					b.Out[0].Tape = tapes[:2]
+				},
+				"", "",
+				"failed to rebuild locking script of output 0: tape 1 cell 1: 1 chunks were left out of the tapes before it, they cannot be rebuilt",
+			},
+			{
+				"cell changed",
+				func(b *Tx) {
+					h := "63"
+					b.Out[0].Tape[2].Cell[0].S, b.Out[0].Tape[2].Cell[0].H = nil, &h
+				},
+				"006a0161017c0163",
+				"006a0161017c0163",
+				"",
+			},
+		}
+	)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			bobTx, err := NewFromTx(tx)
+			require.NoError(t, err)
+			test.modify(bobTx)
+
+			// the same tapes give the same script, whether parsed or decoded
+			var str string
+			str, err = bobTx.ToString()
+			require.NoError(t, err)
+			var decoded *Tx
+			decoded, err = NewFromString(str)
+			require.NoError(t, err)
+
+			for _, b := range []*Tx{bobTx, decoded} {
+				var rebuilt *transaction.Transaction
+				rebuilt, err = b.ToTx()
+				if test.expectedError != "" {
+					require.EqualError(t, err, test.expectedError)
+				} else {
+					require.NoError(t, err)
+					require.Equal(t, test.expectedScript, rebuilt.Outputs[0].LockingScript.String())
+				}
+
+				// the original tx is only used while the cells match it
+				rebuilt, err = b.ToTxWithOptions(ToTxOptions{Original: tx})
+				if test.expectedError != "" {
+					require.EqualError(t, err, test.expectedError)
+				} else {
+					require.NoError(t, err)
+					require.Equal(t, test.expectedOriginal, rebuilt.Outputs[0].LockingScript.String())
+				}
+			}
+		})
+	}
+}
+
+// testRule returns a pointer to the split rule
+func testRule(rule SplitRule) *SplitRule {
+	return &rule
+}
+
+// TestTx_ToTx_Errors tests the errors returned when a script cannot be rebuilt
+func TestTx_ToTx_Errors(t *testing.T) {
+	t.Parallel()
+
+	var (
+		// Testing broken cells
+		tests = []struct {
+			name          string
+			modify        func(b *Tx)
+			expectedError string
+		}{
+			{
+				"invalid hex",
+				func(b *Tx) {
+					h := "zz"
+					b.Out[0].Tape[1].Cell[1].H = &h
+				},
+				`failed to rebuild locking script of output 0: tape 1 cell 1: invalid hex "zz"`,
+			},
+			{
+				"invalid base64",
+				func(b *Tx) {
+					str := "!!"
+					b.Out[0].Tape[1].Cell[0].H = nil
+					b.Out[0].Tape[1].Cell[0].B = &str
+				},
+				`failed to rebuild locking script of output 0: tape 1 cell 0: invalid base64 "!!"`,
+			},
+			{
+				"no data",
+				func(b *Tx) {
+					b.Out[0].Tape[1].Cell[0] = bpu.Cell{II: b.Out[0].Tape[1].Cell[0].II}
+				},
+				"failed to rebuild locking script of output 0: tape 1 cell 0: cell has no data",
+			},
+			{
+				"push opcode too small",
+				func(b *Tx) {
+					op := script.OpDATA1
+					b.Out[0].Tape[1].Cell[0].Op = &op
+				},
+				"failed to rebuild locking script of output 0: tape 1 cell 0: OP_DATA_1 cannot push 21 bytes",
+			},
+			{
+				"chunks left out",
+				func(b *Tx) {
+					b.Out[0].Tape[1].Cell[1].II += 2
+				},
+				"failed to rebuild locking script of output 0: tape 1 cell 1: 2 chunks were left out of the tapes before it, they cannot be rebuilt",
+			},
+			{
+				"input",
+				func(b *Tx) {
+					h := "0"
+					b.In[0].Tape[0].Cell[1].H = &h
+				},
+				"failed to rebuild unlocking script of input 0: tape 0 cell 1: invalid hex",
+			},
+		}
+	)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			bobTx, err := NewFromString(sampleBobTx)
+			require.NoError(t, err)
+			test.modify(bobTx)
+
+			_, err = bobTx.ToTx()
+			require.Error(t, err)
+			require.Contains(t, err.Error(), test.expectedError)
+		})
+	}
+}
+
+// ExampleTx_ToTxWithOptions_original example of a round trip preserving non-minimal pushes
+func ExampleTx_ToTxWithOptions_original() {
+	// OP_FALSE OP_RETURN OP_PUSHDATA1 <"hi">
+	tx := transaction.NewTransaction()
+	s, _ := script.NewFromHex("006a4c026869")
+	tx.AddOutput(&transaction.TransactionOutput{LockingScript: s})
+
+	b, err := NewFromTx(tx)
+	if err != nil {
+		fmt.Printf("error occurred: %s", err.Error())
+		return
+	}
+
+	var rebuilt *transaction.Transaction
+	if rebuilt, err = b.ToTxWithOptions(ToTxOptions{Original: tx}); err != nil {
+		fmt.Printf("error occurred: %s", err.Error())
+		return
+	}
+	fmt.Printf("locking script: %s", hex.EncodeToString(*rebuilt.Outputs[0].LockingScript))
+	// Output:locking script: 006a4c026869
+}
+
+// BenchmarkTx_ToTxWithOptions_Original benchmarks the method ToTxWithOptions() with the original tx
+func BenchmarkTx_ToTxWithOptions_Original(b *testing.B) {
+	tx := testNonMinimalPushesTx()
+	bobTx, _ := NewFromTx(tx)
+	opts := ToTxOptions{Original: tx}
+	for i := 0; i < b.N; i++ {
+		_, _ = bobTx.ToTxWithOptions(opts)
+	}
+}
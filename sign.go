@@ -0,0 +1,179 @@
+package bob
+
+import (
+	"fmt"
+
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/bsv-blockchain/go-sdk/script"
+	"github.com/bsv-blockchain/go-sdk/transaction"
+	feemodel "github.com/bsv-blockchain/go-sdk/transaction/fee_model"
+	sighash "github.com/bsv-blockchain/go-sdk/transaction/sighash"
+	"github.com/bsv-blockchain/go-sdk/transaction/template/p2pkh"
+)
+
+// SignOptions configures how a transaction is finished by Sign
+type SignOptions struct {
+	// Keys sign the P2PKH inputs spending their (compressed or uncompressed) address
+	Keys []*ec.PrivateKey
+
+	// FeeRate is the fee in satoshis per kB
+	FeeRate uint64
+
+	// ChangeAddress receives the change, defaults to the address of the first key.
+	// No change output is added if there is no change left after the fee.
+	ChangeAddress string
+
+	// SourceOutputs looks up the outputs spent by the inputs of a Tx (see ToTxOptions)
+	SourceOutputs SourceOutputLookup
+}
+
+// Sign adds a change output, pays the fee and signs the P2PKH inputs of the tx
+//
+// It returns the signed raw tx and the signed tx in BOB format. Inputs must
+// have a known value, either from E.V or from opts.SourceOutputs.
+func (t *Tx) Sign(opts SignOptions) (string, *Tx, error) {
+	if opts.SourceOutputs == nil {
+		for i := range t.In {
+			if t.In[i].E.V == nil {
+				return "", nil, fmt.Errorf("input %d satoshis are not set", i)
+			}
+		}
+	}
+	tx, err := t.ToTxWithOptions(ToTxOptions{SourceOutputs: opts.SourceOutputs})
+	if err != nil {
+		return "", nil, err
+	}
+	return signTx(tx, opts)
+}
+
+// Sign adds a change output, pays the fee and signs the inputs of the built tx
+//
+// Inputs whose utxo has an UnlockingScriptTemplate are signed with it, the
+// other inputs must be P2PKH inputs of one of the keys.
+func (b *Builder) Sign(opts SignOptions) (string, *Tx, error) {
+	tx, err := b.Transaction()
+	if err != nil {
+		return "", nil, err
+	}
+	return signTx(tx, opts)
+}
+
+// signTx finishes and signs the tx
+func signTx(tx *transaction.Transaction, opts SignOptions) (string, *Tx, error) {
+	if len(opts.Keys) == 0 {
+		return "", nil, fmt.Errorf("at least one key must be set")
+	}
+
+	keys := make(map[string]transaction.UnlockingScriptTemplate)
+	for i, key := range opts.Keys {
+		if key == nil {
+			return "", nil, fmt.Errorf("key %d must be set", i)
+		}
+		for _, compressed := range []bool{true, false} {
+			a, err := script.NewAddressFromPublicKeyWithCompression(key.PubKey(), true, compressed)
+			if err != nil {
+				return "", nil, err
+			}
+			if !compressed {
+				keys[a.AddressString] = &uncompressedP2PKH{key: key}
+				continue
+			}
+			if keys[a.AddressString], err = p2pkh.Unlock(key, nil); err != nil {
+				return "", nil, err
+			}
+		}
+	}
+
+	for i, in := range tx.Inputs {
+		source := in.SourceTxOutput()
+		if source == nil {
+			return "", nil, fmt.Errorf("input %d source output is not known", i)
+		}
+		if in.UnlockingScriptTemplate != nil {
+			in.UnlockingScript = nil
+			continue
+		}
+		if source.LockingScript == nil || !source.LockingScript.IsP2PKH() {
+			return "", nil, fmt.Errorf("input %d does not spend a P2PKH output", i)
+		}
+		a, err := source.LockingScript.Address()
+		if err != nil {
+			return "", nil, fmt.Errorf("input %d: %w", i, err)
+		}
+		template, ok := keys[a.AddressString]
+		if !ok {
+			return "", nil, fmt.Errorf("input %d: no key for address %s", i, a.AddressString)
+		}
+		in.UnlockingScriptTemplate = template
+		// the unlocking script size is estimated from the template
+		in.UnlockingScript = nil
+	}
+
+	changeAddress := opts.ChangeAddress
+	if changeAddress == "" {
+		a, err := script.NewAddressFromPublicKey(opts.Keys[0].PubKey(), true)
+		if err != nil {
+			return "", nil, err
+		}
+		changeAddress = a.AddressString
+	}
+	a, err := script.NewAddressFromString(changeAddress)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid change address %q: %w", changeAddress, err)
+	}
+	var change *script.Script
+	if change, err = p2pkh.Lock(a); err != nil {
+		return "", nil, err
+	}
+	tx.AddOutput(&transaction.TransactionOutput{LockingScript: change, Change: true})
+
+	if err = tx.Fee(&feemodel.SatoshisPerKilobyte{Satoshis: opts.FeeRate}, transaction.ChangeDistributionEqual); err != nil {
+		return "", nil, fmt.Errorf("failed to pay the fee: %w", err)
+	}
+	if err = tx.Sign(); err != nil {
+		return "", nil, fmt.Errorf("failed to sign: %w", err)
+	}
+
+	var bobTx *Tx
+	if bobTx, err = newFromSourcedTx(tx); err != nil {
+		return "", nil, err
+	}
+	return tx.String(), bobTx, nil
+}
+
+// uncompressedP2PKH unlocks P2PKH outputs of the uncompressed address of
+// the key, p2pkh.Unlock only pushing the compressed public key
+type uncompressedP2PKH struct {
+	key *ec.PrivateKey
+}
+
+// Sign returns the unlocking script of the input: the signature and the
+// uncompressed public key
+func (u *uncompressedP2PKH) Sign(tx *transaction.Transaction, inputIndex uint32) (*script.Script, error) {
+	if tx.Inputs[inputIndex].SourceTxOutput() == nil {
+		return nil, transaction.ErrEmptyPreviousTx
+	}
+	sh, err := tx.CalcInputSignatureHash(inputIndex, sighash.AllForkID)
+	if err != nil {
+		return nil, err
+	}
+	var sig *ec.Signature
+	if sig, err = u.key.Sign(sh); err != nil {
+		return nil, err
+	}
+
+	s := &script.Script{}
+	if err = s.AppendPushData(append(sig.Serialize(), uint8(sighash.AllForkID))); err != nil {
+		return nil, err
+	}
+	if err = s.AppendPushData(u.key.PubKey().Uncompressed()); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// EstimateLength returns the length of the unlocking script, the same
+// estimate as p2pkh with a public key 32 bytes longer
+func (u *uncompressedP2PKH) EstimateLength(_ *transaction.Transaction, _ uint32) uint32 {
+	return 106 + 32
+}
@@ -0,0 +1,257 @@
+package bob
+
+import (
+	"fmt"
+	"testing"
+
+	test "github.com/bitcoinschema/go-bob/testing"
+	"github.com/bsv-blockchain/go-sdk/chainhash"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/bsv-blockchain/go-sdk/script"
+	"github.com/bsv-blockchain/go-sdk/script/interpreter"
+	"github.com/bsv-blockchain/go-sdk/transaction"
+	"github.com/bsv-blockchain/go-sdk/transaction/template/p2pkh"
+	"github.com/stretchr/testify/require"
+)
+
+// testVerifyInputs runs the scripts of every input of the raw tx against the utxos
+func testVerifyInputs(t testing.TB, rawTx string, utxos ...*transaction.UTXO) {
+	tx, err := transaction.NewTransactionFromHex(rawTx)
+	require.NoError(t, err)
+	require.Len(t, tx.Inputs, len(utxos))
+	for i, utxo := range utxos {
+		prevOutput := &transaction.TransactionOutput{LockingScript: utxo.LockingScript, Satoshis: utxo.Satoshis}
+		require.NoError(t, interpreter.NewEngine().Execute(
+			interpreter.WithTx(tx, i, prevOutput),
+			interpreter.WithForkID(),
+			interpreter.WithAfterGenesis(),
+		))
+	}
+}
+
+// TestBuilder_Sign tests signing a built tx with change and fee
+func TestBuilder_Sign(t *testing.T) {
+	t.Parallel()
+
+	key1, key2 := test.Key(1), test.Key(2)
+	address1, address2, address3 := test.Address(key1), test.Address(key2), test.Address(test.Key(3))
+	utxo1, utxo2 := testUTXO(t, address1, 10000), testUTXO(t, address2, 5000)
+	utxo2.Vout = 2
+
+	rawTx, bobTx, err := NewBuilder().
+		AddInput(utxo1).
+		AddInput(utxo2).
+		AddTape("19HxigV4QyBv3tHpQVcUEQyq1pzZVdoAut", []byte("hello world"), []byte("text/plain")).
+		AddP2PKHOutput(address3.AddressString, 1000).
+		Sign(SignOptions{Keys: []*ec.PrivateKey{key1, key2}, FeeRate: 1000})
+	require.NoError(t, err)
+	testVerifyInputs(t, rawTx, utxo1, utxo2)
+
+	// the change goes to the first key
+	require.Len(t, bobTx.Out, 3)
+	require.Equal(t, address1.AddressString, *bobTx.Out[2].E.A)
+	change := *bobTx.Out[2].E.V
+	fee := 15000 - 1000 - change
+	size := uint64(len(rawTx) / 2)
+	require.InDelta(t, size, fee, 2, "fee of %d for %d bytes", fee, size)
+
+	// the re-parsed tx matches the raw tx and knows the spent outputs
+	rebuilt, err := bobTx.ToRawTxString()
+	require.NoError(t, err)
+	require.Equal(t, rawTx, rebuilt)
+	require.Equal(t, uint64(5000), *bobTx.In[1].E.V)
+	require.Equal(t, address2.AddressString, *bobTx.In[1].E.A)
+}
+
+// TestBuilder_Sign_Uncompressed tests signing inputs of compressed and uncompressed addresses
+func TestBuilder_Sign_Uncompressed(t *testing.T) {
+	t.Parallel()
+
+	key := test.Key(1)
+	uncompressed, err := script.NewAddressFromPublicKeyWithCompression(key.PubKey(), true, false)
+	require.NoError(t, err)
+	utxo1, utxo2 := testUTXO(t, uncompressed, 10000), testUTXO(t, test.Address(key), 5000)
+	utxo2.Vout = 2
+
+	rawTx, bobTx, err := NewBuilder().
+		AddInput(utxo1).
+		AddInput(utxo2).
+		AddTape("prefix", []byte("data")).
+		Sign(SignOptions{Keys: []*ec.PrivateKey{key}, FeeRate: 1000})
+	require.NoError(t, err)
+	testVerifyInputs(t, rawTx, utxo1, utxo2)
+	require.Equal(t, uncompressed.AddressString, *bobTx.In[0].E.A)
+
+	// the fee is estimated with the longer public key
+	fee := 15000 - *bobTx.Out[1].E.V
+	size := uint64(len(rawTx) / 2)
+	require.InDelta(t, size, fee, 2, "fee of %d for %d bytes", fee, size)
+}
+
+// TestTx_Sign tests signing a BOB tx
+func TestTx_Sign(t *testing.T) {
+	t.Parallel()
+
+	key := test.Key(1)
+	address := test.Address(key)
+	utxo := testUTXO(t, address, 10000)
+
+	t.Run("from builder tx", func(t *testing.T) {
+		unsigned, err := NewBuilder().AddInput(utxo).AddTape("prefix", []byte("data")).Tx()
+		require.NoError(t, err)
+
+		rawTx, bobTx, err := unsigned.Sign(SignOptions{
+			Keys:          []*ec.PrivateKey{key},
+			FeeRate:       50,
+			ChangeAddress: test.Address(test.Key(2)).AddressString,
+		})
+		require.NoError(t, err)
+		testVerifyInputs(t, rawTx, utxo)
+		require.Len(t, bobTx.Out, 2)
+		require.Equal(t, test.Address(test.Key(2)).AddressString, *bobTx.Out[1].E.A)
+		require.Less(t, *bobTx.Out[1].E.V, uint64(10000))
+	})
+
+	t.Run("with source outputs", func(t *testing.T) {
+		unsigned, err := NewBuilder().AddInput(utxo).AddTape("prefix", []byte("data")).Tx()
+		require.NoError(t, err)
+		unsigned.In[0].E.V = nil
+
+		_, _, err = unsigned.Sign(SignOptions{Keys: []*ec.PrivateKey{key}})
+		require.Error(t, err)
+
+		var rawTx string
+		rawTx, _, err = unsigned.Sign(SignOptions{
+			Keys: []*ec.PrivateKey{key},
+			SourceOutputs: func(string, uint32) (*transaction.TransactionOutput, error) {
+				return &transaction.TransactionOutput{LockingScript: utxo.LockingScript, Satoshis: utxo.Satoshis}, nil
+			},
+		})
+		require.NoError(t, err)
+		testVerifyInputs(t, rawTx, utxo)
+	})
+}
+
+// TestSign_Errors tests txs that cannot be signed
+func TestSign_Errors(t *testing.T) {
+	t.Parallel()
+
+	key := test.Key(1)
+	address := test.Address(key)
+
+	t.Run("no keys", func(t *testing.T) {
+		_, _, err := NewBuilder().AddInput(testUTXO(t, address, 1000)).Sign(SignOptions{})
+		require.Error(t, err)
+	})
+
+	t.Run("nil key", func(t *testing.T) {
+		_, _, err := NewBuilder().AddInput(testUTXO(t, address, 1000)).Sign(SignOptions{Keys: []*ec.PrivateKey{nil}})
+		require.Error(t, err)
+	})
+
+	t.Run("no key for input", func(t *testing.T) {
+		_, _, err := NewBuilder().
+			AddInput(testUTXO(t, test.Address(test.Key(2)), 1000)).
+			Sign(SignOptions{Keys: []*ec.PrivateKey{key}})
+		require.Error(t, err)
+	})
+
+	t.Run("insufficient funds", func(t *testing.T) {
+		_, _, err := NewBuilder().
+			AddInput(testUTXO(t, address, 1000)).
+			AddP2PKHOutput(address.AddressString, 1000).
+			Sign(SignOptions{Keys: []*ec.PrivateKey{key}, FeeRate: 100})
+		require.Error(t, err)
+	})
+
+	t.Run("invalid change address", func(t *testing.T) {
+		_, _, err := NewBuilder().
+			AddInput(testUTXO(t, address, 1000)).
+			Sign(SignOptions{Keys: []*ec.PrivateKey{key}, ChangeAddress: "1invalid"})
+		require.Error(t, err)
+	})
+
+	t.Run("builder error", func(t *testing.T) {
+		_, _, err := NewBuilder().AddInput(nil).Sign(SignOptions{Keys: []*ec.PrivateKey{key}})
+		require.Error(t, err)
+	})
+}
+
+// TestBuilder_Sign_NoChange tests that the change output is left out when
+// there is no change left
+func TestBuilder_Sign_NoChange(t *testing.T) {
+	t.Parallel()
+
+	key := test.Key(1)
+	address := test.Address(key)
+	utxo := testUTXO(t, address, 1000)
+
+	rawTx, bobTx, err := NewBuilder().
+		AddInput(utxo).
+		AddP2PKHOutput(address.AddressString, 1000).
+		Sign(SignOptions{Keys: []*ec.PrivateKey{key}})
+	require.NoError(t, err)
+	require.Len(t, bobTx.Out, 1)
+	testVerifyInputs(t, rawTx, utxo)
+}
+
+// TestBuilder_Sign_Template tests signing an input with its utxo template
+func TestBuilder_Sign_Template(t *testing.T) {
+	t.Parallel()
+
+	key1, key2 := test.Key(1), test.Key(2)
+	utxo := testUTXO(t, test.Address(key2), 1000)
+	var err error
+	utxo.UnlockingScriptTemplate, err = p2pkh.Unlock(key2, nil)
+	require.NoError(t, err)
+
+	rawTx, _, err := NewBuilder().AddInput(utxo).Sign(SignOptions{Keys: []*ec.PrivateKey{key1}})
+	require.NoError(t, err)
+	testVerifyInputs(t, rawTx, utxo)
+}
+
+// ExampleBuilder_Sign example using Sign()
+func ExampleBuilder_Sign() {
+	key, err := ec.PrivateKeyFromHex(fmt.Sprintf("%064x", 1))
+	if err != nil {
+		fmt.Printf("error occurred: %s", err.Error())
+		return
+	}
+	var address *script.Address
+	if address, err = script.NewAddressFromPublicKey(key.PubKey(), true); err != nil {
+		fmt.Printf("error occurred: %s", err.Error())
+		return
+	}
+	var lock *script.Script
+	if lock, err = p2pkh.Lock(address); err != nil {
+		fmt.Printf("error occurred: %s", err.Error())
+		return
+	}
+	var txID *chainhash.Hash
+	if txID, err = chainhash.NewHashFromHex(testUTXOTxID); err != nil {
+		fmt.Printf("error occurred: %s", err.Error())
+		return
+	}
+
+	var bobTx *Tx
+	if _, bobTx, err = NewBuilder().
+		AddInput(&transaction.UTXO{TxID: txID, LockingScript: lock, Satoshis: 1000}).
+		AddTape("19HxigV4QyBv3tHpQVcUEQyq1pzZVdoAut", []byte("hello world"), []byte("text/plain")).
+		Sign(SignOptions{Keys: []*ec.PrivateKey{key}, FeeRate: 1000}); err != nil {
+		fmt.Printf("error occurred: %s", err.Error())
+		return
+	}
+	fmt.Printf("change of %d satoshis to %s", *bobTx.Out[1].E.V, *bobTx.Out[1].E.A)
+	// Output:change of 740 satoshis to 1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMH
+}
+
+// BenchmarkBuilder_Sign benchmarks the method Sign()
+func BenchmarkBuilder_Sign(b *testing.B) {
+	key := test.Key(1)
+	builder := NewBuilder().
+		AddInput(testUTXO(b, test.Address(key), 10000)).
+		AddTape("19HxigV4QyBv3tHpQVcUEQyq1pzZVdoAut", []byte("hello world"), []byte("text/plain"))
+	for i := 0; i < b.N; i++ {
+		_, _, _ = builder.Sign(SignOptions{Keys: []*ec.PrivateKey{key}, FeeRate: 1000})
+	}
+}
@@ -0,0 +1,319 @@
+package bob
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/bitcoinschema/go-bpu"
+	"github.com/bsv-blockchain/go-sdk/script"
+	"github.com/bsv-blockchain/go-sdk/transaction"
+)
+
+// DefaultSplitConfig returns the split configuration used by FromRawTxString
+// and FromTx (and their WithOptions variants when none is given)
+//
+// Scripts are split into tapes:
+//   - after OP_RETURN, which ends the tape it is in
+//   - after OP_FALSE, which ends the tape it is in, once an OP_RETURN was seen
+//   - on the protocol delimiter "|", which is left out, once an OP_RETURN was seen
+//
+// A new slice is returned on every call, so it can safely be modified.
+func DefaultSplitConfig() []bpu.SplitConfig {
+	var separator = ProtocolDelimiter
+	var l = bpu.IncludeL
+	var opReturn = uint8(106)
+	var opFalse = uint8(0)
+	return []bpu.SplitConfig{
+		{
+			Token: &bpu.Token{
+				Op: &opReturn,
+			},
+			Include: &l,
+		},
+		{
+			Token: &bpu.Token{
+				Op: &opFalse,
+			},
+			Include: &l,
+			Require: &opReturn,
+		},
+		{
+			Token: &bpu.Token{
+				S: &separator,
+			},
+			Require: &opReturn,
+		},
+	}
+}
+
+// SplitInclude determines which tape the token of a split rule ends up in
+type SplitInclude int
+
+// Split include behaviors
+const (
+	// SplitExclude leaves the token out of the tapes
+	SplitExclude SplitInclude = iota
+
+	// SplitIncludeLeft keeps the token as the last cell of the tape it ends
+	SplitIncludeLeft
+
+	// SplitIncludeRight keeps the token as the first cell of the tape it starts
+	SplitIncludeRight
+)
+
+// SplitRule describes a token scripts are split into tapes on
+//
+// Exactly one of Op and String must be set.
+type SplitRule struct {
+	// Op splits on this opcode
+	Op *uint8
+
+	// String splits on a pushdata matching this literal string
+	String *string
+
+	// Include determines which tape the token ends up in
+	Include SplitInclude
+
+	// Require only splits once this opcode (or a single byte pushdata of
+	// the same value) was seen earlier in the script
+	Require *uint8
+}
+
+// NewOpSplitRule creates a SplitRule splitting on the given opcode
+func NewOpSplitRule(op uint8, include SplitInclude) SplitRule {
+	return SplitRule{Op: &op, Include: include}
+}
+
+// NewStringSplitRule creates a SplitRule splitting on pushdatas matching the given string
+func NewStringSplitRule(s string, include SplitInclude) SplitRule {
+	return SplitRule{String: &s, Include: include}
+}
+
+// WithRequire returns a copy of the rule that only splits once the
+// given opcode was seen earlier in the script
+func (r SplitRule) WithRequire(op uint8) SplitRule {
+	r.Require = &op
+	return r
+}
+
+// DefaultSplitRules returns the rules matching DefaultSplitConfig
+func DefaultSplitRules() []SplitRule {
+	return []SplitRule{
+		NewOpSplitRule(script.OpRETURN, SplitIncludeLeft),
+		NewOpSplitRule(script.OpFALSE, SplitIncludeLeft).WithRequire(script.OpRETURN),
+		NewStringSplitRule(ProtocolDelimiter, SplitExclude).WithRequire(script.OpRETURN),
+	}
+}
+
+// validate checks that the rule has exactly one token and a known include behavior
+func (r SplitRule) validate() error {
+	if (r.Op == nil) == (r.String == nil) {
+		return fmt.Errorf("split rule must have exactly one of Op or String set")
+	}
+	if r.Include < SplitExclude || r.Include > SplitIncludeRight {
+		return fmt.Errorf("split rule has unknown include behavior: %d", r.Include)
+	}
+	return nil
+}
+
+// splitConfig translates the rule to a bpu.SplitConfig
+//
+// Include right is parsed as include left, the token is moved to the
+// next tape afterward (see moveIncludeRightTokens)
+func (r SplitRule) splitConfig() bpu.SplitConfig {
+	config := bpu.SplitConfig{
+		Token:   &bpu.Token{Op: r.Op, S: r.String},
+		Require: r.Require,
+	}
+	if r.Include != SplitExclude {
+		l := bpu.IncludeL
+		config.Include = &l
+	}
+	return config
+}
+
+// matches returns true if the cell is the token of the rule
+func (r SplitRule) matches(cell *bpu.Cell) bool {
+	op, isOp := cellOpcode(cell)
+	if r.Op != nil {
+		return isOp && op == *r.Op
+	}
+	if isOp {
+		// bpu also splits on opcodes matching a single character string (OP_SWAP for "|")
+		return len(*r.String) == 1 && (*r.String)[0] == op
+	}
+	return cell.S != nil && *cell.S == *r.String
+}
+
+// Parser parses transactions into BOB format using a set of split rules
+//
+// Register split rules before using the parser, after that it is safe
+// for concurrent use.
+type Parser struct {
+	opts  ParseOptions
+	rules []SplitRule
+}
+
+// NewParser creates a new Parser starting with the default split rules
+func NewParser() *Parser {
+	return NewParserWithOptions(ParseOptions{})
+}
+
+// NewParserWithOptions creates a new Parser using the given parse options
+//
+// If opts.SplitConfig is set, it is used in place of the default
+// split rules and registered rules are applied after it.
+func NewParserWithOptions(opts ParseOptions) *Parser {
+	p := &Parser{opts: opts}
+	if opts.SplitConfig == nil {
+		p.rules = DefaultSplitRules()
+	}
+	return p
+}
+
+// AddSplitRule registers a split rule on the parser
+func (p *Parser) AddSplitRule(rule SplitRule) error {
+	if err := rule.validate(); err != nil {
+		return err
+	}
+	p.rules = append(p.rules, rule)
+	return nil
+}
+
+// ClearSplitRules removes all split rules from the parser, including the default ones
+func (p *Parser) ClearSplitRules() {
+	p.rules = nil
+	p.opts.SplitConfig = nil
+}
+
+// SplitRules returns a copy of the split rules registered on the parser
+func (p *Parser) SplitRules() []SplitRule {
+	return append([]SplitRule(nil), p.rules...)
+}
+
+// FromRawTxString creates a new BobTx from a hex encoded raw tx string
+func (p *Parser) FromRawTxString(rawTxString string) (*Tx, error) {
+	bobTx := new(Tx)
+	if err := bobTx.FromRawTxStringWithOptions(rawTxString, p.options()); err != nil {
+		return nil, err
+	}
+	p.moveIncludeRightTokens(bobTx)
+	return bobTx, nil
+}
+
+// FromTx creates a new BobTx from a libsv Transaction
+func (p *Parser) FromTx(tx *transaction.Transaction) (*Tx, error) {
+	bobTx := new(Tx)
+	if err := bobTx.FromTxWithOptions(tx, p.options()); err != nil {
+		return nil, err
+	}
+	p.moveIncludeRightTokens(bobTx)
+	return bobTx, nil
+}
+
+// options returns the parse options with the split rules translated
+func (p *Parser) options() ParseOptions {
+	opts := p.opts
+	config := make([]bpu.SplitConfig, 0, len(p.opts.SplitConfig)+len(p.rules))
+	config = append(config, p.opts.SplitConfig...)
+	for _, rule := range p.rules {
+		config = append(config, rule.splitConfig())
+	}
+	opts.SplitConfig = config
+	return opts
+}
+
+// moveIncludeRightTokens moves the tokens of include right rules from
+// the end of the tape they ended to the start of the next tape
+func (p *Parser) moveIncludeRightTokens(t *Tx) {
+	var rules []SplitRule
+	for _, rule := range p.rules {
+		if rule.Include == SplitIncludeRight {
+			rules = append(rules, rule)
+		}
+	}
+	if len(rules) == 0 {
+		return
+	}
+
+	for i := range t.In {
+		t.In[i].Tape = moveTokens(t.In[i].Tape, rules)
+	}
+	for i := range t.Out {
+		t.Out[i].Tape = moveTokens(t.Out[i].Tape, rules)
+	}
+}
+
+// moveTokens moves include right tokens ending a tape to the start of the next tape
+func moveTokens(tapes []bpu.Tape, rules []SplitRule) []bpu.Tape {
+	var seen []bpu.Cell
+	moved := false
+	for tapeIdx := 0; tapeIdx < len(tapes); tapeIdx++ {
+		cells := tapes[tapeIdx].Cell
+		if moved {
+			// the token at the start of this tape was already seen
+			cells = cells[1:]
+			moved = false
+		}
+		if len(cells) == 0 {
+			continue
+		}
+		seen = append(seen, cells[:len(cells)-1]...)
+		token := cells[len(cells)-1]
+		for _, rule := range rules {
+			if rule.matches(&token) && requireMet(rule.Require, seen) {
+				moved = true
+				break
+			}
+		}
+		seen = append(seen, token)
+		if !moved {
+			continue
+		}
+
+		tape := &tapes[tapeIdx]
+		tape.Cell = tape.Cell[:len(tape.Cell)-1]
+		if tapeIdx == len(tapes)-1 {
+			tapes = append(tapes, bpu.Tape{I: tape.I + 1})
+		}
+		next := &tapes[tapeIdx+1]
+		token.I = 0
+		for i := range next.Cell {
+			next.Cell[i].I++
+		}
+		next.Cell = append([]bpu.Cell{token}, next.Cell...)
+	}
+
+	// a token at the very start leaves an empty tape behind,
+	// the tapes after it are renumbered
+	kept := tapes[:0]
+	for _, tape := range tapes {
+		if len(tape.Cell) > 0 {
+			kept = append(kept, tape)
+		}
+	}
+	if len(kept) < len(tapes) {
+		for i := range kept {
+			kept[i].I = uint8(i)
+		}
+	}
+	return kept
+}
+
+// requireMet returns true if the required opcode is found in the cells
+// seen so far, matching the precondition check of bpu
+func requireMet(require *uint8, seen []bpu.Cell) bool {
+	if require == nil {
+		return true
+	}
+	for i := range seen {
+		if op, ok := cellOpcode(&seen[i]); ok {
+			if op == *require {
+				return true
+			}
+		} else if seen[i].H != nil && *seen[i].H == hex.EncodeToString([]byte{*require}) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,295 @@
+package bob
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	test "github.com/bitcoinschema/go-bob/testing"
+	"github.com/bitcoinschema/go-bpu"
+	"github.com/bsv-blockchain/go-sdk/script"
+	"github.com/bsv-blockchain/go-sdk/transaction"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDefaultSplitConfig_Parity tests that FromRawTxString and FromTx give identical results for every fixture
+func TestDefaultSplitConfig_Parity(t *testing.T) {
+	t.Parallel()
+
+	files, err := filepath.Glob("./testing/tx/*.hex")
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+
+	for _, file := range files {
+		rawTx := test.GetTestHex(file)
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			fromRaw, err := NewFromRawTxString(rawTx)
+			require.NoError(t, err)
+
+			tx, err := transaction.NewTransactionFromHex(rawTx)
+			require.NoError(t, err)
+
+			var fromTx *Tx
+			fromTx, err = NewFromTx(tx)
+			require.NoError(t, err)
+
+			require.Equal(t, fromRaw, fromTx)
+		})
+	}
+}
+
+// TestDefaultSplitConfig_OpFalse tests that FromTx splits on OP_FALSE after an OP_RETURN like FromRawTxString
+func TestDefaultSplitConfig_OpFalse(t *testing.T) {
+	t.Parallel()
+
+	tx := transaction.NewTransaction()
+	s := script.NewFromBytes([]byte{})
+	_ = s.AppendOpcodes(script.OpFALSE, script.OpRETURN)
+	_ = s.AppendPushDataString("prefix")
+	_ = s.AppendOpcodes(script.OpFALSE)
+	_ = s.AppendPushDataString("data")
+	tx.AddOutput(&transaction.TransactionOutput{LockingScript: s})
+
+	fromTx, err := NewFromTx(tx)
+	require.NoError(t, err)
+	fromRaw, err := NewFromRawTxString(tx.String())
+	require.NoError(t, err)
+
+	require.Equal(t, fromRaw, fromTx)
+	require.Len(t, fromTx.Out[0].Tape, 3)
+	require.Equal(t, "prefix", *fromTx.Out[0].Tape[1].Cell[0].S)
+	require.Equal(t, uint8(0), *fromTx.Out[0].Tape[1].Cell[1].Op)
+	require.Equal(t, "data", *fromTx.Out[0].Tape[2].Cell[0].S)
+}
+
+// TestDefaultSplitConfig tests that every call returns a fresh configuration
+func TestDefaultSplitConfig(t *testing.T) {
+	t.Parallel()
+
+	config := DefaultSplitConfig()
+	require.Len(t, config, 3)
+	*config[0].Token.Op = 0x51
+	config[2].Token.S = nil
+
+	fresh := DefaultSplitConfig()
+	require.Equal(t, uint8(106), *fresh[0].Token.Op)
+	require.Equal(t, ProtocolDelimiter, *fresh[2].Token.S)
+}
+
+// BenchmarkDefaultSplitConfig benchmarks the method DefaultSplitConfig()
+func BenchmarkDefaultSplitConfig(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_ = DefaultSplitConfig()
+	}
+}
+
+// testSplitRulesTx returns a tx with an output using custom delimiters
+func testSplitRulesTx() *transaction.Transaction {
+	tx := transaction.NewTransaction()
+	s := script.NewFromBytes([]byte{})
+	_ = s.AppendPushDataString("a")
+	_ = s.AppendPushDataString("b")
+	_ = s.AppendOpcodes(script.OpCODESEPARATOR)
+	_ = s.AppendPushDataString("c")
+	_ = s.AppendPushDataString("MARK")
+	_ = s.AppendPushDataString("d")
+	tx.AddOutput(&transaction.TransactionOutput{LockingScript: s})
+	return tx
+}
+
+// tapeStrings returns the cells of the tapes as strings, using the opcode name for opcodes
+func tapeStrings(tapes []bpu.Tape) (out [][]string) {
+	for _, tape := range tapes {
+		var cells []string
+		for _, cell := range tape.Cell {
+			if cell.Ops != nil {
+				cells = append(cells, *cell.Ops)
+			} else {
+				cells = append(cells, *cell.S)
+			}
+		}
+		out = append(out, cells)
+	}
+	return
+}
+
+// TestParser_SplitRules tests parsing with custom split rules
+func TestParser_SplitRules(t *testing.T) {
+	t.Parallel()
+
+	var (
+		// Testing include behaviors
+		tests = []struct {
+			name          string
+			include       SplitInclude
+			expectedTapes [][]string
+		}{
+			{"exclude", SplitExclude, [][]string{{"a", "b"}, {"c"}, {"d"}}},
+			{"include left", SplitIncludeLeft, [][]string{{"a", "b", "OP_CODESEPARATOR"}, {"c", "MARK"}, {"d"}}},
+			{"include right", SplitIncludeRight, [][]string{{"a", "b"}, {"OP_CODESEPARATOR", "c"}, {"MARK", "d"}}},
+		}
+	)
+
+	tx := testSplitRulesTx()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := NewParser()
+			require.NoError(t, p.AddSplitRule(NewOpSplitRule(script.OpCODESEPARATOR, test.include)))
+			require.NoError(t, p.AddSplitRule(NewStringSplitRule("MARK", test.include)))
+
+			b, err := p.FromTx(tx)
+			require.NoError(t, err)
+			require.Equal(t, test.expectedTapes, tapeStrings(b.Out[0].Tape))
+			for _, tape := range b.Out[0].Tape {
+				for i, cell := range tape.Cell {
+					require.Equal(t, uint8(i), cell.I)
+				}
+			}
+
+			var fromRaw *Tx
+			fromRaw, err = p.FromRawTxString(tx.String())
+			require.NoError(t, err)
+			require.Equal(t, b, fromRaw)
+		})
+	}
+}
+
+// TestParser_IncludeRightEdges tests include right tokens at the start and end of a script
+func TestParser_IncludeRightEdges(t *testing.T) {
+	t.Parallel()
+
+	tx := transaction.NewTransaction()
+	s := script.NewFromBytes([]byte{})
+	_ = s.AppendPushDataString("MARK")
+	_ = s.AppendPushDataString("a")
+	_ = s.AppendPushDataString("MARK")
+	tx.AddOutput(&transaction.TransactionOutput{LockingScript: s})
+
+	s = script.NewFromBytes([]byte{})
+	_ = s.AppendPushDataString("MARK")
+	_ = s.AppendPushDataString("a")
+	_ = s.AppendPushDataString("MARK")
+	_ = s.AppendPushDataString("b")
+	tx.AddOutput(&transaction.TransactionOutput{LockingScript: s})
+
+	p := NewParser()
+	require.NoError(t, p.AddSplitRule(NewStringSplitRule("MARK", SplitIncludeRight)))
+	b, err := p.FromTx(tx)
+	require.NoError(t, err)
+	require.Equal(t, [][]string{{"MARK", "a"}, {"MARK"}}, tapeStrings(b.Out[0].Tape))
+	require.Equal(t, [][]string{{"MARK", "a"}, {"MARK", "b"}}, tapeStrings(b.Out[1].Tape))
+
+	// the tapes are renumbered after the empty tape is dropped
+	for _, out := range b.Out {
+		for tapeIdx, tape := range out.Tape {
+			require.Equal(t, uint8(tapeIdx), tape.I)
+		}
+	}
+}
+
+// TestParser_Require tests split rules with a precondition
+func TestParser_Require(t *testing.T) {
+	t.Parallel()
+
+	p := NewParser()
+	p.ClearSplitRules()
+	require.Empty(t, p.SplitRules())
+	require.NoError(t, p.AddSplitRule(NewStringSplitRule("MARK", SplitExclude).WithRequire(script.OpCODESEPARATOR)))
+
+	tx := transaction.NewTransaction()
+	s := script.NewFromBytes([]byte{})
+	_ = s.AppendPushDataString("a")
+	_ = s.AppendPushDataString("MARK")
+	_ = s.AppendOpcodes(script.OpCODESEPARATOR)
+	_ = s.AppendPushDataString("b")
+	_ = s.AppendPushDataString("MARK")
+	_ = s.AppendPushDataString("c")
+	tx.AddOutput(&transaction.TransactionOutput{LockingScript: s})
+
+	b, err := p.FromTx(tx)
+	require.NoError(t, err)
+	require.Equal(t, [][]string{{"a", "MARK", "OP_CODESEPARATOR", "b"}, {"c"}}, tapeStrings(b.Out[0].Tape))
+}
+
+// TestParser_Defaults tests that a new parser matches the default parsing
+func TestParser_Defaults(t *testing.T) {
+	t.Parallel()
+
+	p := NewParser()
+	require.Len(t, p.SplitRules(), 3)
+
+	b, err := p.FromRawTxString(rawBobTx)
+	require.NoError(t, err)
+
+	var expected *Tx
+	expected, err = NewFromRawTxString(rawBobTx)
+	require.NoError(t, err)
+	require.Equal(t, expected, b)
+
+	p = NewParserWithOptions(ParseOptions{Mode: ModeDeep, SkipInputs: true})
+	b, err = p.FromTx(testManyPushesTx())
+	require.NoError(t, err)
+	require.Len(t, b.Out[0].Tape[1].Cell, 300)
+
+	_, err = p.FromTx(nil)
+	require.Error(t, err)
+	_, err = p.FromRawTxString("invalid-tx")
+	require.Error(t, err)
+}
+
+// TestParser_AddSplitRule tests validation of split rules
+func TestParser_AddSplitRule(t *testing.T) {
+	t.Parallel()
+
+	op := uint8(script.OpCODESEPARATOR)
+	str := "MARK"
+
+	var (
+		// Testing invalid rules
+		tests = []struct {
+			rule          SplitRule
+			expectedError bool
+		}{
+			{SplitRule{}, true},
+			{SplitRule{Op: &op, String: &str}, true},
+			{SplitRule{Op: &op, Include: SplitInclude(5)}, true},
+			{SplitRule{Op: &op}, false},
+			{SplitRule{String: &str, Include: SplitIncludeRight}, false},
+		}
+	)
+
+	for _, test := range tests {
+		err := NewParser().AddSplitRule(test.rule)
+		if test.expectedError {
+			require.Error(t, err)
+		} else {
+			require.NoError(t, err)
+		}
+	}
+}
+
+// ExampleParser_AddSplitRule example using a custom split rule
+func ExampleParser_AddSplitRule() {
+	p := NewParser()
+	if err := p.AddSplitRule(NewOpSplitRule(script.OpCODESEPARATOR, SplitExclude)); err != nil {
+		fmt.Printf("error occurred: %s", err.Error())
+		return
+	}
+
+	b, err := p.FromTx(testSplitRulesTx())
+	if err != nil {
+		fmt.Printf("error occurred: %s", err.Error())
+		return
+	}
+	fmt.Printf("found tapes: %d", len(b.Out[0].Tape))
+	// Output:found tapes: 2
+}
+
+// BenchmarkParser_FromRawTxString benchmarks the method FromRawTxString()
+func BenchmarkParser_FromRawTxString(b *testing.B) {
+	p := NewParser()
+	_ = p.AddSplitRule(NewStringSplitRule("MARK", SplitIncludeRight))
+	for i := 0; i < b.N; i++ {
+		_, _ = p.FromRawTxString(rawBobTx)
+	}
+}
@@ -0,0 +1,151 @@
+package bob
+
+import (
+	"fmt"
+
+	"github.com/bitcoinschema/go-bpu"
+	"github.com/bsv-blockchain/go-sdk/script"
+)
+
+// Tape is a bpu.Tape with accessors that return errors instead of
+// panicking on missing cells
+type Tape bpu.Tape
+
+// Cell is a bpu.Cell with accessors that decode its data from
+// whichever of H, B or S is present
+type Cell bpu.Cell
+
+// Tapes returns the tapes of all outputs in order
+func (t *Tx) Tapes() []*Tape {
+	var tapes []*Tape
+	for i := range t.Out {
+		for j := range t.Out[i].Tape {
+			tapes = append(tapes, (*Tape)(&t.Out[i].Tape[j]))
+		}
+	}
+	return tapes
+}
+
+// TapeByPrefix returns the first output tape whose first cell is the given
+// protocol prefix (like a B:// or MAP address), or nil if there is none
+func (t *Tx) TapeByPrefix(prefix string) *Tape {
+	for _, tape := range t.Tapes() {
+		if p, err := tape.Prefix(); err == nil && p == prefix {
+			return tape
+		}
+	}
+	return nil
+}
+
+// OpReturnOutputs returns the outputs that contain an OP_RETURN
+func (t *Tx) OpReturnOutputs() []*bpu.Output {
+	var outputs []*bpu.Output
+	for i := range t.Out {
+		if hasOpReturn(t.Out[i].Tape) {
+			outputs = append(outputs, &t.Out[i])
+		}
+	}
+	return outputs
+}
+
+// hasOpReturn returns true if any cell of the tapes is an OP_RETURN
+func hasOpReturn(tapes []bpu.Tape) bool {
+	for i := range tapes {
+		for j := range tapes[i].Cell {
+			if isOp(&tapes[i].Cell[j], script.OpRETURN) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// CellAt returns the cell at the given index of the tape
+func (t *Tape) CellAt(i int) (*Cell, error) {
+	if t == nil {
+		return nil, fmt.Errorf("tape is nil")
+	}
+	if i < 0 || i >= len(t.Cell) {
+		return nil, fmt.Errorf("tape has no cell %d (%d cells)", i, len(t.Cell))
+	}
+	return (*Cell)(&t.Cell[i]), nil
+}
+
+// Prefix returns the first cell of the tape as a string
+func (t *Tape) Prefix() (string, error) {
+	c, err := t.CellAt(0)
+	if err != nil {
+		return "", err
+	}
+	return c.String()
+}
+
+// Opcode returns the opcode of the cell, or false if it is a pushdata
+func (c *Cell) Opcode() (uint8, bool) {
+	if c == nil {
+		return 0, false
+	}
+	return cellOpcode((*bpu.Cell)(c))
+}
+
+// Bytes returns the data pushed by the cell
+func (c *Cell) Bytes() ([]byte, error) {
+	if c == nil {
+		return nil, fmt.Errorf("cell is nil")
+	}
+	if op, ok := c.Opcode(); ok {
+		return nil, fmt.Errorf("cell is the opcode %s", script.OpCodeValues[op])
+	}
+	return cellBytes((*bpu.Cell)(c))
+}
+
+// String returns the data pushed by the cell as a string
+func (c *Cell) String() (string, error) {
+	data, err := c.Bytes()
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// Int returns the number pushed by the cell, read the way a script would
+//
+// OP_0, OP_1NEGATE and OP_1 through OP_16 give their value, pushdatas
+// are decoded as little endian sign and magnitude numbers of up to 8 bytes.
+func (c *Cell) Int() (int64, error) {
+	if op, ok := c.Opcode(); ok {
+		switch {
+		case op == script.Op0:
+			return 0, nil
+		case op == script.Op1NEGATE:
+			return -1, nil
+		case op >= script.Op1 && op <= script.Op16:
+			return int64(op-script.Op1) + 1, nil
+		}
+		return 0, fmt.Errorf("cell is the opcode %s", script.OpCodeValues[op])
+	}
+
+	data, err := c.Bytes()
+	if err != nil {
+		return 0, err
+	}
+	if len(data) > 8 {
+		return 0, fmt.Errorf("number of %d bytes is too large", len(data))
+	}
+	if len(data) == 0 {
+		return 0, nil
+	}
+
+	var n int64
+	for i, b := range data {
+		n |= int64(b) << (8 * i)
+	}
+
+	// the most significant bit of the last byte is the sign
+	last := len(data) - 1
+	if data[last]&0x80 != 0 {
+		n &^= int64(0x80) << (8 * last)
+		n = -n
+	}
+	return n, nil
+}
@@ -0,0 +1,239 @@
+package bob
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/bitcoinschema/go-bpu"
+	"github.com/bsv-blockchain/go-sdk/script"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTx_Tapes tests the methods Tapes(), TapeByPrefix() and OpReturnOutputs()
+func TestTx_Tapes(t *testing.T) {
+	t.Parallel()
+
+	bobTx, err := NewFromRawTxString(rawBobTx)
+	require.NoError(t, err)
+
+	tapes := bobTx.Tapes()
+	require.Len(t, tapes, 6)
+
+	var (
+		// Testing protocol prefixes
+		tests = []struct {
+			prefix        string
+			expectedCells int
+			expectedNil   bool
+		}{
+			{"19HxigV4QyBv3tHpQVcUEQyq1pzZVdoAut", 5, false},
+			{"1PuQa7K62MiKCtssSLKy1kh56WWU7MtUR5", 20, false},
+			{"15PciHG22SNLQJXMoSUaWVi7WSqc7hCfva", 4, false},
+			{"1BAPSuaPnfGnSBM3GLV9yhxUdYe4vGbdMT", 0, true},
+			{"", 0, true},
+		}
+	)
+
+	for _, test := range tests {
+		tape := bobTx.TapeByPrefix(test.prefix)
+		if test.expectedNil {
+			require.Nil(t, tape)
+			continue
+		}
+		require.NotNil(t, tape)
+		require.Len(t, tape.Cell, test.expectedCells)
+	}
+
+	outputs := bobTx.OpReturnOutputs()
+	require.Len(t, outputs, 1)
+	require.Equal(t, uint8(0), outputs[0].I)
+
+	// tapes point into the tx
+	tape := bobTx.TapeByPrefix("19HxigV4QyBv3tHpQVcUEQyq1pzZVdoAut")
+	s := "changed"
+	tape.Cell[1].S = &s
+	require.Equal(t, "changed", *bobTx.Out[0].Tape[1].Cell[1].S)
+}
+
+// TestTape_CellAt tests the method CellAt()
+func TestTape_CellAt(t *testing.T) {
+	t.Parallel()
+
+	bobTx, err := NewFromRawTxString(rawBobTx)
+	require.NoError(t, err)
+	tape := bobTx.TapeByPrefix("19HxigV4QyBv3tHpQVcUEQyq1pzZVdoAut")
+
+	var c *Cell
+	c, err = tape.CellAt(2)
+	require.NoError(t, err)
+	require.Equal(t, "text/plain", mustString(t, c))
+
+	_, err = tape.CellAt(5)
+	require.Error(t, err)
+	_, err = tape.CellAt(-1)
+	require.Error(t, err)
+
+	var nilTape *Tape
+	_, err = nilTape.CellAt(0)
+	require.Error(t, err)
+	_, err = nilTape.Prefix()
+	require.Error(t, err)
+
+	_, err = (&Tape{}).Prefix()
+	require.Error(t, err)
+}
+
+// mustString returns the cell as a string, failing the test on error
+func mustString(t testing.TB, c *Cell) string {
+	s, err := c.String()
+	require.NoError(t, err)
+	return s
+}
+
+// TestCell_Bytes tests the methods Bytes() and String() with every data field
+func TestCell_Bytes(t *testing.T) {
+	t.Parallel()
+
+	h, b, s := "68656c6c6f", "aGVsbG8=", "hello"
+	badH, badB := "zz", "!!"
+	opReturn := script.OpRETURN
+	ops := "OP_RETURN"
+	empty := ""
+
+	var (
+		// Testing cells with different fields set
+		tests = []struct {
+			name          string
+			cell          *Cell
+			expected      string
+			expectedError bool
+		}{
+			{"hex", &Cell{H: &h}, "hello", false},
+			{"base64", &Cell{B: &b}, "hello", false},
+			{"string", &Cell{S: &s}, "hello", false},
+			{"large base64", &Cell{LB: &b}, "hello", false},
+			{"large string", &Cell{LS: &s}, "hello", false},
+			{"hex first", &Cell{H: &h, B: &badB, S: &empty}, "hello", false},
+			{"empty", &Cell{H: &empty}, "", false},
+			{"invalid hex", &Cell{H: &badH}, "", true},
+			{"invalid base64", &Cell{B: &badB}, "", true},
+			{"no data", &Cell{}, "", true},
+			{"opcode", &Cell{Op: &opReturn, Ops: &ops, H: &empty, S: &empty}, "", true},
+			{"nil", nil, "", true},
+		}
+	)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			str, err := test.cell.String()
+			data, bytesErr := test.cell.Bytes()
+			if test.expectedError {
+				require.Error(t, err)
+				require.Error(t, bytesErr)
+				return
+			}
+			require.NoError(t, err)
+			require.NoError(t, bytesErr)
+			require.Equal(t, test.expected, str)
+			require.Equal(t, []byte(test.expected), data)
+		})
+	}
+}
+
+// TestCell_Int tests the method Int()
+func TestCell_Int(t *testing.T) {
+	t.Parallel()
+
+	op := func(o uint8) *Cell {
+		return &Cell{Op: &o}
+	}
+	push := func(h string) *Cell {
+		return &Cell{H: &h}
+	}
+
+	var (
+		// Testing opcodes and script numbers
+		tests = []struct {
+			name          string
+			cell          *Cell
+			expected      int64
+			expectedError bool
+		}{
+			{"OP_0", op(script.Op0), 0, false},
+			{"OP_1NEGATE", op(script.Op1NEGATE), -1, false},
+			{"OP_1", op(script.Op1), 1, false},
+			{"OP_16", op(script.Op16), 16, false},
+			{"empty", push(""), 0, false},
+			{"one byte", push("7f"), 127, false},
+			{"negative", push("81"), -1, false},
+			{"two bytes", push("8000"), 128, false},
+			{"negative two bytes", push("8080"), -128, false},
+			{"eight bytes", push("ffffffffffffff7f"), 9223372036854775807, false},
+			{"negative eight bytes", push("ffffffffffffffff"), -9223372036854775807, false},
+			{"too large", push("000000000000000001"), 0, true},
+			{"other opcode", op(script.OpRETURN), 0, true},
+			{"nil", nil, 0, true},
+		}
+	)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			n, err := test.cell.Int()
+			if test.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expected, n)
+		})
+	}
+}
+
+// TestCell_Opcode tests the method Opcode() on cells parsed by bpu
+func TestCell_Opcode(t *testing.T) {
+	t.Parallel()
+
+	bobTx, err := NewFromRawTxString(rawBobTx)
+	require.NoError(t, err)
+
+	c, err := (*Tape)(&bobTx.Out[0].Tape[0]).CellAt(1)
+	require.NoError(t, err)
+	op, ok := c.Opcode()
+	require.True(t, ok)
+	require.Equal(t, script.OpRETURN, op)
+
+	c = (*Cell)(&bpu.Cell{})
+	_, ok = c.Opcode()
+	require.False(t, ok)
+}
+
+// ExampleTx_TapeByPrefix example using TapeByPrefix()
+func ExampleTx_TapeByPrefix() {
+	b, err := NewFromRawTxString(rawBobTx)
+	if err != nil {
+		fmt.Printf("error occurred: %s", err.Error())
+		return
+	}
+
+	var c *Cell
+	if c, err = b.TapeByPrefix("19HxigV4QyBv3tHpQVcUEQyq1pzZVdoAut").CellAt(2); err != nil {
+		fmt.Printf("error occurred: %s", err.Error())
+		return
+	}
+
+	var mediaType string
+	if mediaType, err = c.String(); err != nil {
+		fmt.Printf("error occurred: %s", err.Error())
+		return
+	}
+	fmt.Printf("found media type: %s", mediaType)
+	// Output:found media type: text/plain
+}
+
+// BenchmarkTx_TapeByPrefix benchmarks the method TapeByPrefix()
+func BenchmarkTx_TapeByPrefix(b *testing.B) {
+	bobTx, _ := NewFromRawTxString(rawBobTx)
+	for i := 0; i < b.N; i++ {
+		_ = bobTx.TapeByPrefix("15PciHG22SNLQJXMoSUaWVi7WSqc7hCfva")
+	}
+}
@@ -2,8 +2,13 @@
 package test
 
 import (
+	"encoding/binary"
 	"os"
 	"strings"
+
+	"github.com/bitcoinschema/go-bpu"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/bsv-blockchain/go-sdk/script"
 )
 
 // GetTestHex gets the hex of the given test file
@@ -15,3 +20,44 @@ func GetTestHex(fileName string) string {
 
 	return strings.Trim(string(fileData), "\n")
 }
+
+// Tape returns a tape with a string cell for every value
+//
+// It returns a bpu.Tape (convert it with (*bob.Tape)(tape)) so the tests of
+// the bob package itself can use this package.
+func Tape(values ...string) *bpu.Tape {
+	tape := &bpu.Tape{}
+	for i := range values {
+		tape.Cell = append(tape.Cell, bpu.Cell{S: &values[i], I: uint8(i)})
+	}
+	return tape
+}
+
+// Key returns the deterministic private key of the seed, the seed must be positive
+func Key(seed int) *ec.PrivateKey {
+	b := make([]byte, 32)
+	binary.BigEndian.PutUint64(b[24:], uint64(seed))
+	key, _ := ec.PrivateKeyFromBytes(b)
+	return key
+}
+
+// Address returns the mainnet address of the compressed public key of the key
+func Address(key *ec.PrivateKey) *script.Address {
+	// it only fails for keys that are not valid
+	address, _ := script.NewAddressFromPublicKey(key.PubKey(), true)
+	return address
+}
+
+// AppendInscription appends a 1Sat ordinals inscription envelope to the script,
+// with the content split into the given pushes
+func AppendInscription(s *script.Script, contentType string, content ...[]byte) {
+	_ = s.AppendOpcodes(script.OpFALSE, script.OpIF)
+	_ = s.AppendPushDataString("ord")
+	_ = s.AppendOpcodes(script.Op1)
+	_ = s.AppendPushDataString(contentType)
+	_ = s.AppendOpcodes(script.Op0)
+	for _, c := range content {
+		_ = s.AppendPushData(c)
+	}
+	_ = s.AppendOpcodes(script.OpENDIF)
+}
@@ -0,0 +1,168 @@
+package bob
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"unicode/utf8"
+
+	"github.com/bitcoinschema/go-bpu"
+)
+
+// Mismatch describes an input or output of a BOB tx that does not match
+// the transaction it was rebuilt into
+type Mismatch struct {
+	Index  int    `json:"i"`
+	Reason string `json:"reason"`
+}
+
+// VerifyReport is the result of verifying a BOB tx against its txid
+type VerifyReport struct {
+	Line         int        `json:"line,omitempty"`
+	TxID         string     `json:"txid"`
+	ComputedTxID string     `json:"computed_txid,omitempty"`
+	Inputs       []Mismatch `json:"inputs,omitempty"`
+	Outputs      []Mismatch `json:"outputs,omitempty"`
+	Err          error      `json:"-"`
+}
+
+// Valid returns true if the rebuilt tx matches the txid and no input or output differs
+func (r *VerifyReport) Valid() bool {
+	return r.Err == nil && r.TxID != "" && r.TxID == r.ComputedTxID &&
+		len(r.Inputs) == 0 && len(r.Outputs) == 0
+}
+
+// Verify rebuilds the transaction with ToTx, recomputes the txid and
+// compares it with Tx.Tx.H (the txid the document claims)
+//
+// Inputs and outputs are checked on their own as well, so the report
+// lists which of them hold data that can not be part of the transaction
+// (cells whose H, B and S disagree, outputs without satoshis, scripts
+// whose left out chunks can not be rebuilt). When the txid does not
+// match, the assumptions made rebuilding the scripts (such as a left out
+// chunk being the protocol delimiter) are listed too. An error is
+// returned if the transaction can not be rebuilt for another reason.
+func (t *Tx) Verify() (*VerifyReport, error) {
+	report := &VerifyReport{TxID: t.Tx.Tx.H}
+	var (
+		inAssumptions  = make([][]string, len(t.In))
+		outAssumptions = make([][]string, len(t.Out))
+		unbuildable    bool
+	)
+	for i := range t.In {
+		reason := verifyTapes(t.In[i].Tape)
+		if reason == "" {
+			reason, inAssumptions[i] = verifyScript(t.In[i].Tape)
+			unbuildable = unbuildable || reason != ""
+		}
+		if reason != "" {
+			report.Inputs = append(report.Inputs, Mismatch{Index: i, Reason: reason})
+		}
+	}
+	for i := range t.Out {
+		reason := verifyTapes(t.Out[i].Tape)
+		if reason == "" {
+			reason, outAssumptions[i] = verifyScript(t.Out[i].Tape)
+			unbuildable = unbuildable || reason != ""
+		}
+		if reason == "" && t.Out[i].E.V == nil {
+			reason = "satoshis are not set"
+		}
+		if reason != "" {
+			report.Outputs = append(report.Outputs, Mismatch{Index: i, Reason: reason})
+		}
+	}
+
+	tx, err := t.ToTx()
+	if err != nil {
+		if unbuildable {
+			// the scripts that can not be rebuilt are reported
+			return report, nil
+		}
+		return report, err
+	}
+	report.ComputedTxID = tx.TxID().String()
+	if report.ComputedTxID != report.TxID {
+		report.Inputs = appendAssumptions(report.Inputs, inAssumptions)
+		report.Outputs = appendAssumptions(report.Outputs, outAssumptions)
+	}
+	return report, nil
+}
+
+// verifyScript returns the reason the script of the tapes can not be
+// rebuilt, or the assumptions made rebuilding it
+func verifyScript(tapes []bpu.Tape) (string, []string) {
+	_, assumptions, err := tapesToScript(tapes, nil)
+	if err != nil {
+		return err.Error(), nil
+	}
+	return "", assumptions
+}
+
+// appendAssumptions appends the assumptions made rebuilding each script as mismatches
+func appendAssumptions(mismatches []Mismatch, assumptions [][]string) []Mismatch {
+	for i := range assumptions {
+		for _, assumption := range assumptions[i] {
+			mismatches = append(mismatches, Mismatch{Index: i, Reason: assumption})
+		}
+	}
+	return mismatches
+}
+
+// VerifyStream verifies every BOB tx of a newline delimited JSON stream
+//
+// Transactions that can not be rebuilt are reported with Err set, the
+// stream is only stopped by a line that is not a valid BOB tx.
+func VerifyStream(r io.Reader) ([]*VerifyReport, error) {
+	var reports []*VerifyReport
+	dec := NewDecoder(r)
+	for dec.Next() {
+		report, err := dec.Tx().Verify()
+		report.Line = dec.Line()
+		report.Err = err
+		reports = append(reports, report)
+	}
+	return reports, dec.Err()
+}
+
+// verifyTapes returns the reason the tapes can not match a script,
+// or an empty string if they are consistent
+func verifyTapes(tapes []bpu.Tape) string {
+	for tapeIdx := range tapes {
+		for cellIdx := range tapes[tapeIdx].Cell {
+			if err := verifyCell(&tapes[tapeIdx].Cell[cellIdx]); err != nil {
+				return fmt.Sprintf("tape %d cell %d: %s", tapeIdx, cellIdx, err.Error())
+			}
+		}
+	}
+	return ""
+}
+
+// verifyCell checks that the H, B and S values of a pushdata cell hold the same data
+//
+// S is only compared for valid UTF-8 data, since binary data does not
+// survive being encoded as a JSON string.
+func verifyCell(cell *bpu.Cell) error {
+	if _, ok := cellOpcode(cell); ok {
+		return nil
+	}
+	data, err := cellBytes(cell)
+	if err != nil {
+		return err
+	}
+	if cell.B != nil {
+		var b []byte
+		if b, err = base64.StdEncoding.DecodeString(*cell.B); err != nil {
+			return fmt.Errorf("invalid base64 %q: %w", *cell.B, err)
+		}
+		if !bytes.Equal(b, data) {
+			return fmt.Errorf("b does not match h %q", hex.EncodeToString(data))
+		}
+	}
+	if cell.S != nil && utf8.Valid(data) && *cell.S != string(data) {
+		return fmt.Errorf("s does not match h %q", hex.EncodeToString(data))
+	}
+	return nil
+}
@@ -0,0 +1,243 @@
+package bob
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bsv-blockchain/go-sdk/script"
+	"github.com/bsv-blockchain/go-sdk/transaction"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTx_Verify tests the method Verify()
+func TestTx_Verify(t *testing.T) {
+	t.Parallel()
+
+	var (
+		// Testing valid and tampered documents
+		tests = []struct {
+			name            string
+			bob             string
+			modify          func(b *Tx)
+			expectedValid   bool
+			expectedMatch   bool
+			expectedOutputs []Mismatch
+		}{
+			{"sample", sampleBobTx, func(_ *Tx) {}, true, true, nil},
+			{"parity", parityBob, func(_ *Tx) {}, true, true, nil},
+			{
+				"different satoshis",
+				sampleBobTx,
+				func(b *Tx) {
+					v := uint64(1000)
+					b.Out[0].E.V = &v
+				},
+				false, false, nil,
+			},
+			{
+				"different data",
+				sampleBobTx,
+				func(b *Tx) {
+					h := "00"
+					b.Out[0].Tape[1].Cell[0].H = &h
+				},
+				false, false,
+				[]Mismatch{{Index: 0, Reason: `tape 1 cell 0: b does not match h "00"`}},
+			},
+			{
+				"different string",
+				sampleBobTx,
+				func(b *Tx) {
+					s := "tampered"
+					b.Out[0].Tape[1].Cell[0].S = &s
+				},
+				false, true,
+				[]Mismatch{{Index: 0, Reason: `tape 1 cell 0: s does not match h "e4b880e781afe883bde999a4e58d83e5b9b4e69a97"`}},
+			},
+			{
+				"missing satoshis",
+				sampleBobTx,
+				func(b *Tx) {
+					b.Out[0].E.V = nil
+				},
+				false, true,
+				[]Mismatch{{Index: 0, Reason: "satoshis are not set"}},
+			},
+			{
+				"wrong txid",
+				sampleBobTx,
+				func(b *Tx) {
+					b.Tx.Tx.H = "0000000000000000000000000000000000000000000000000000000000000000"
+				},
+				false, false, nil,
+			},
+		}
+	)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			bobTx, err := NewFromString(test.bob)
+			require.NoError(t, err)
+			test.modify(bobTx)
+
+			var report *VerifyReport
+			report, err = bobTx.Verify()
+			require.NoError(t, err)
+			require.Equal(t, test.expectedValid, report.Valid())
+			require.Equal(t, test.expectedMatch, report.TxID == report.ComputedTxID)
+			require.Empty(t, report.Inputs)
+			require.Equal(t, test.expectedOutputs, report.Outputs)
+		})
+	}
+}
+
+// TestTx_Verify_RawTx tests verifying txs parsed from every raw tx fixture
+func TestTx_Verify_RawTx(t *testing.T) {
+	t.Parallel()
+
+	for _, rawTx := range []string{rawBobTx, parityTx, boostTx} {
+		bobTx, err := NewFromRawTxString(rawTx)
+		require.NoError(t, err)
+
+		var report *VerifyReport
+		report, err = bobTx.Verify()
+		require.NoError(t, err)
+		require.True(t, report.Valid())
+	}
+}
+
+// TestTx_Verify_Error tests a document that can not be rebuilt
+func TestTx_Verify_Error(t *testing.T) {
+	t.Parallel()
+
+	bobTx, err := NewFromString(sampleBobTxBadStrings)
+	require.NoError(t, err)
+
+	var report *VerifyReport
+	report, err = bobTx.Verify()
+	require.Error(t, err)
+	require.NotNil(t, report)
+	require.False(t, report.Valid())
+	require.Empty(t, report.ComputedTxID)
+	require.Len(t, report.Outputs, 1)
+	require.Equal(t, 0, report.Outputs[0].Index)
+}
+
+// TestTx_Verify_LeftOutChunks tests reporting scripts whose left out chunks are not known from the tapes
+func TestTx_Verify_LeftOutChunks(t *testing.T) {
+	t.Parallel()
+
+	truncated := []byte{script.OpFALSE, script.OpRETURN}
+	for i := 0; i < 300; i++ {
+		truncated = append(truncated, script.OpDATA2, byte(i), byte(i>>8))
+	}
+
+	var (
+		// Testing documents of scripts with left out chunks
+		tests = []struct {
+			name            string
+			script          string
+			expectedValid   bool
+			expectedOutputs []Mismatch
+		}{
+			{"protocol delimiter", "006a0568656c6c6f017c03616263", true, nil},
+			{
+				"OP_SWAP",
+				"006a0568656c6c6f7c03616263",
+				false,
+				[]Mismatch{{Index: 0, Reason: `tape 2 cell 0: the chunk left out before it is assumed to be the protocol delimiter "|"`}},
+			},
+			{
+				"truncated",
+				hex.EncodeToString(truncated),
+				false,
+				[]Mismatch{{Index: 0, Reason: "tape 1 cell 126: the chunks before it were probably dropped by shallow parsing (see ModeDeep), the script cannot be rebuilt"}},
+			},
+		}
+	)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s, err := script.NewFromHex(test.script)
+			require.NoError(t, err)
+			tx := transaction.NewTransaction()
+			tx.AddOutput(&transaction.TransactionOutput{LockingScript: s, Satoshis: 1})
+
+			var bobTx *Tx
+			bobTx, err = NewFromTx(tx)
+			require.NoError(t, err)
+
+			// the parsed tx and its document give the same report
+			var str string
+			str, err = bobTx.ToString()
+			require.NoError(t, err)
+			var decoded *Tx
+			decoded, err = NewFromString(str)
+			require.NoError(t, err)
+
+			for _, b := range []*Tx{bobTx, decoded} {
+				var report *VerifyReport
+				report, err = b.Verify()
+				require.NoError(t, err)
+				require.Equal(t, test.expectedValid, report.Valid())
+				require.Empty(t, report.Inputs)
+				require.Equal(t, test.expectedOutputs, report.Outputs)
+			}
+		})
+	}
+}
+
+// TestVerifyStream tests verifying an NDJSON stream
+func TestVerifyStream(t *testing.T) {
+	t.Parallel()
+
+	stream := compactLine(t, sampleBobTx) + "\n" +
+		compactLine(t, sampleBobTxBadStrings) + "\n\n" +
+		compactLine(t, parityBob) + "\n"
+
+	reports, err := VerifyStream(strings.NewReader(stream))
+	require.NoError(t, err)
+	require.Len(t, reports, 3)
+
+	require.True(t, reports[0].Valid())
+	require.Equal(t, 1, reports[0].Line)
+
+	require.False(t, reports[1].Valid())
+	require.Error(t, reports[1].Err)
+	require.Equal(t, 2, reports[1].Line)
+
+	require.True(t, reports[2].Valid())
+	require.Equal(t, 4, reports[2].Line)
+
+	// an invalid line stops the stream
+	reports, err = VerifyStream(strings.NewReader(compactLine(t, sampleBobTx) + "\nnot-json\n"))
+	require.Error(t, err)
+	require.Len(t, reports, 1)
+}
+
+// ExampleTx_Verify example using Verify()
+func ExampleTx_Verify() {
+	b, err := NewFromString(sampleBobTx)
+	if err != nil {
+		fmt.Printf("error occurred: %s", err.Error())
+		return
+	}
+
+	var report *VerifyReport
+	if report, err = b.Verify(); err != nil {
+		fmt.Printf("error occurred: %s", err.Error())
+		return
+	}
+	fmt.Printf("valid: %t", report.Valid())
+	// Output:valid: true
+}
+
+// BenchmarkTx_Verify benchmarks the method Verify()
+func BenchmarkTx_Verify(b *testing.B) {
+	bobTx, _ := NewFromString(sampleBobTx)
+	for i := 0; i < b.N; i++ {
+		_, _ = bobTx.Verify()
+	}
+}